@@ -2,18 +2,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"net/http"
+	"os"
+
+	"github.com/SangamSilwal/httpkit/client"
 )
 
 func main() {
 	fmt.Println("Checking Web Request")
 
-	response, err := http.Get("https://hiteshchoudhary.com/index.html")
+	// one client with timeouts instead of http.Get, which waits forever on a stuck server
+	web, err := client.New(client.DefaultConfig())
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
 
+	response, err := web.Get(context.Background(), "https://hiteshchoudhary.com/index.html")
 	if err != nil {
-		panic(err)
+		// no panic, a *client.StatusError also carries the status code and the start of the body
+		fmt.Println("Request failed:", err)
+		os.Exit(1)
 	}
 
 	fmt.Printf("Response is of Type: %T\n", response)
@@ -21,8 +32,8 @@ func main() {
 
 	dataBytes, err := io.ReadAll(response.Body)
 	if err != nil {
-		panic(err)
-
+		fmt.Println("Reading the body failed:", err)
+		return
 	}
 	fmt.Println(string(dataBytes))
 }
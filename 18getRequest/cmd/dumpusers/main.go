@@ -0,0 +1,85 @@
+// dumpusers writes every user of the randomusers API as one JSON object per line
+//
+//	go run ./cmd/dumpusers -max 100 > users.ndjson
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"getrequest/randomusers"
+
+	"github.com/SangamSilwal/httpkit/client"
+)
+
+func main() {
+	baseURL := flag.String("base-url", randomusers.BaseURL, "API to read from")
+	pageSize := flag.Int("limit", 50, "users per page")
+	prefetch := flag.Int("prefetch", 2, "pages fetched ahead of the one being written")
+	maxUsers := flag.Int("max", 0, "stop after this many users, 0 means all")
+	out := flag.String("o", "-", "file to write, - for stdout")
+	flag.Parse()
+
+	// Ctrl+C stops the walk and still flushes what was written
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, *baseURL, *pageSize, *prefetch, *maxUsers, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "dumpusers:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, baseURL string, pageSize, prefetch, maxUsers int, out string) error {
+	if prefetch < 0 {
+		return fmt.Errorf("-prefetch must be 0 or more, got %d", prefetch)
+	}
+	cfg := client.DefaultConfig()
+	cfg.BaseURL = baseURL
+	cfg.Retry = client.DefaultRetryPolicy()
+	breaker := client.DefaultBreakerConfig()
+	cfg.Breaker = &breaker
+	api, err := client.New(cfg)
+	if err != nil {
+		return err
+	}
+	users := randomusers.New(api)
+	users.PageSize = pageSize
+	users.Prefetch = prefetch
+
+	var w io.Writer = os.Stdout
+	if out != "-" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	buf := bufio.NewWriter(w)
+	defer buf.Flush()
+	enc := json.NewEncoder(buf)
+
+	count := 0
+	for user, err := range users.All(ctx) {
+		if err != nil {
+			return fmt.Errorf("after %d users: %w", count, err)
+		}
+		// Encode ends every object with a newline, which is all NDJSON asks for
+		if err := enc.Encode(user); err != nil {
+			return err
+		}
+		count++
+		if count == maxUsers {
+			break
+		}
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d users\n", count)
+	return buf.Flush()
+}
@@ -1,23 +1,56 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
+	"time"
+
+	"github.com/SangamSilwal/httpkit/client"
 )
 
 func main() {
 	fmt.Println("Https methods in golang")
-	PerformGetRequest()
+	if err := PerformGetRequest(); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+}
 
+// newFreeAPIClient is the client for every request to api.freeapi.app
+func newFreeAPIClient() (*client.Client, error) {
+	cfg := client.DefaultConfig()
+	cfg.BaseURL = "https://api.freeapi.app/api/v1"
+	cfg.Header = http.Header{"Accept": {"application/json"}}
+	// the free API often answers 503 or 429 for a moment, so try again a few times
+	cfg.Retry = client.DefaultRetryPolicy()
+	cfg.Retry.OnAttempt = func(a client.Attempt) {
+		if a.Retry {
+			fmt.Printf("Attempt %d failed: %v, retrying in %v\n", a.Number, a.Err, a.Delay.Round(time.Millisecond))
+		}
+	}
+	// when the API is down, stop calling it for a while instead of waiting on every timeout
+	breaker := client.DefaultBreakerConfig()
+	breaker.OnStateChange = func(c client.StateChange) {
+		fmt.Printf("Circuit for %s is now %s (was %s)\n", c.Host, c.To, c.From)
+	}
+	cfg.Breaker = &breaker
+	return client.New(cfg)
 }
 
-func PerformGetRequest() {
-	const myurl = "https://api.freeapi.app/api/v1/public/randomusers?page=1&limit=10"
-	response, err := http.Get(myurl)
+func PerformGetRequest() error {
+	freeAPI, err := newFreeAPIClient()
 	if err != nil {
-		panic(err)
+		return err
+	}
+	const myurl = "/public/randomusers?page=1&limit=10"
+	response, err := freeAPI.Get(context.Background(), myurl)
+	if err != nil {
+		return err
 	}
 	//Closing the request in Golang
 	defer response.Body.Close()
@@ -29,9 +62,12 @@ func PerformGetRequest() {
 	// fmt.Println(string(content))
 
 	var responseString strings.Builder
-	content, _ := io.ReadAll(response.Body)
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
 	byteCount, _ := responseString.Write(content)
 	fmt.Println(byteCount)
 	// fmt.Println(responseString.String()) --> this will print out all the reponse from the get url
-
+	return nil
 }
@@ -0,0 +1,200 @@
+// Package randomusers reads the paginated randomusers endpoint of api.freeapi.app
+package randomusers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+
+	"github.com/SangamSilwal/httpkit/client"
+)
+
+// BaseURL is where the public API lives
+const BaseURL = "https://api.freeapi.app/api/v1"
+
+// User is one random user, the login secrets the API also sends are left out
+type User struct {
+	ID     int    `json:"id"`
+	Gender string `json:"gender"`
+	Name   struct {
+		Title string `json:"title"`
+		First string `json:"first"`
+		Last  string `json:"last"`
+	} `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	Cell  string `json:"cell"`
+	Nat   string `json:"nat"`
+	Login struct {
+		UUID     string `json:"uuid"`
+		Username string `json:"username"`
+	} `json:"login"`
+	Dob struct {
+		Date string `json:"date"`
+		Age  int    `json:"age"`
+	} `json:"dob"`
+	Location struct {
+		City     string   `json:"city"`
+		State    string   `json:"state"`
+		Country  string   `json:"country"`
+		Postcode Postcode `json:"postcode"`
+	} `json:"location"`
+	Picture struct {
+		Large     string `json:"large"`
+		Medium    string `json:"medium"`
+		Thumbnail string `json:"thumbnail"`
+	} `json:"picture"`
+}
+
+// Postcode comes as a number for some countries and as a string for others
+type Postcode string
+
+func (p *Postcode) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(b, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*p = Postcode(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*p = Postcode(n.String())
+	return nil
+}
+
+// Page is one page of users
+type Page struct {
+	Number     int    `json:"page"`
+	Limit      int    `json:"limit"`
+	TotalPages int    `json:"totalPages"`
+	TotalItems int    `json:"totalItems"`
+	NextPage   bool   `json:"nextPage"`
+	Users      []User `json:"data"`
+}
+
+// envelope is how the API wraps every answer
+type envelope struct {
+	StatusCode int    `json:"statusCode"`
+	Data       Page   `json:"data"`
+	Message    string `json:"message"`
+	Success    bool   `json:"success"`
+}
+
+// Client walks the pages, it is safe for concurrent use
+type Client struct {
+	api *client.Client
+	// PageSize is the limit asked for on every page
+	PageSize int
+	// Prefetch is how many pages are fetched ahead of the one being read, 0 or less fetches one at a time
+	Prefetch int
+}
+
+// New returns a Client using api, whose BaseURL should be BaseURL or a stand-in
+func New(api *client.Client) *Client {
+	return &Client{api: api, PageSize: 10, Prefetch: 2}
+}
+
+// Page fetches one page, pages start at 1
+func (c *Client) Page(ctx context.Context, page int) (Page, error) {
+	q := url.Values{}
+	q.Set("page", strconv.Itoa(page))
+	q.Set("limit", strconv.Itoa(c.PageSize))
+	res, err := client.GetJSON[envelope](ctx, c.api, "/public/randomusers?"+q.Encode())
+	if err != nil {
+		return Page{}, err
+	}
+	if !res.Success {
+		return Page{}, fmt.Errorf("randomusers: page %d: %s", page, res.Message)
+	}
+	return res.Data, nil
+}
+
+// All walks every page lazily, the first page tells how many there are
+// stopping the loop early cancels the pages still being fetched
+// after an error nothing more is yielded
+func (c *Client) All(ctx context.Context) iter.Seq2[User, error] {
+	return func(yield func(User, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+
+		first, err := c.Page(ctx, 1)
+		if err != nil {
+			cancel()
+			yield(User{}, err)
+			return
+		}
+
+		pages, done := c.prefetch(ctx, 2, first.TotalPages)
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		for _, u := range first.Users {
+			if !yield(u, nil) {
+				return
+			}
+		}
+		for next := range pages {
+			res := <-next
+			if res.err != nil {
+				yield(User{}, res.err)
+				return
+			}
+			for _, u := range res.page.Users {
+				if !yield(u, nil) {
+					return
+				}
+			}
+			if !res.page.NextPage {
+				return
+			}
+		}
+	}
+}
+
+type pageResult struct {
+	page Page
+	err  error
+}
+
+// prefetch fetches pages from..to in the background and sends one channel per page, in order,
+// at most Prefetch pages wait unread; done is closed once every fetch has returned
+func (c *Client) prefetch(ctx context.Context, from, to int) (<-chan chan pageResult, <-chan struct{}) {
+	ahead := max(c.Prefetch, 0)
+	pages := make(chan chan pageResult, ahead)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(pages)
+		fetching := make(chan struct{}, max(ahead, 1))
+	loop:
+		for n := from; n <= to; n++ {
+			next := make(chan pageResult, 1)
+			select {
+			case pages <- next:
+			case <-ctx.Done():
+				break loop
+			}
+			// the slot is held until the fetch returns, so never more than Prefetch run at once
+			fetching <- struct{}{}
+			go func() {
+				page, err := c.Page(ctx, n)
+				next <- pageResult{page, err}
+				<-fetching
+			}()
+		}
+		// wait for the last fetches by taking every slot
+		for range cap(fetching) {
+			fetching <- struct{}{}
+		}
+	}()
+	return pages, done
+}
@@ -0,0 +1,175 @@
+package randomusers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SangamSilwal/httpkit/client"
+)
+
+// standIn serves total users the way api.freeapi.app does,
+// failPage answers 404 and delay slows every page down
+type standIn struct {
+	total    int
+	failPage int
+	delay    time.Duration
+
+	requests atomic.Int32
+	inFlight atomic.Int32
+	maxIn    atomic.Int32
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		old := s.maxIn.Load()
+		if n <= old || s.maxIn.CompareAndSwap(old, n) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+
+	if r.URL.Path != "/api/v1/public/randomusers" {
+		http.NotFound(w, r)
+		return
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if page == s.failPage {
+		http.Error(w, `{"message":"page not found"}`, http.StatusNotFound)
+		return
+	}
+
+	totalPages := (s.total + limit - 1) / limit
+	var users []map[string]any
+	for id := (page-1)*limit + 1; id <= min(page*limit, s.total); id++ {
+		users = append(users, map[string]any{
+			"id":       id,
+			"email":    "user" + strconv.Itoa(id) + "@example.com",
+			"location": map[string]any{"postcode": id * 100},
+			"login":    map[string]any{"username": "u" + strconv.Itoa(id), "password": "secret"},
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"statusCode": 200,
+		"success":    true,
+		"message":    "Random users fetched successfully",
+		"data": map[string]any{
+			"page": page, "limit": limit, "totalPages": totalPages, "totalItems": s.total,
+			"nextPage": page < totalPages, "data": users,
+		},
+	})
+}
+
+func newTestClient(t *testing.T, s *standIn) *Client {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	api, err := client.New(client.Config{BaseURL: srv.URL + "/api/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(api)
+	c.PageSize = 3
+	return c
+}
+
+func TestAllWalksEveryPageInOrder(t *testing.T) {
+	s := &standIn{total: 20, delay: 5 * time.Millisecond}
+	c := newTestClient(t, s)
+	c.Prefetch = 3
+
+	want := 1
+	for user, err := range c.All(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.ID != want {
+			t.Fatalf("got user %d, want %d", user.ID, want)
+		}
+		if user.Location.Postcode != Postcode(strconv.Itoa(want*100)) {
+			t.Errorf("postcode = %q, want the number as text", user.Location.Postcode)
+		}
+		want++
+	}
+	if want != 21 {
+		t.Fatalf("walked %d users, want 20", want-1)
+	}
+	if got := s.maxIn.Load(); got > 3 {
+		t.Errorf("%d pages in flight at once, Prefetch is 3", got)
+	}
+	if got := s.maxIn.Load(); got < 2 {
+		t.Errorf("pages were never fetched concurrently")
+	}
+}
+
+func TestNegativePrefetchFetchesOneAtATime(t *testing.T) {
+	s := &standIn{total: 7}
+	c := newTestClient(t, s)
+	c.Prefetch = -1
+
+	count := 0
+	for _, err := range c.All(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count != 7 {
+		t.Errorf("walked %d users, want 7", count)
+	}
+	if got := s.maxIn.Load(); got > 1 {
+		t.Errorf("%d pages in flight at once, want 1", got)
+	}
+}
+
+func TestAllStopsEarly(t *testing.T) {
+	s := &standIn{total: 300}
+	c := newTestClient(t, s)
+	c.Prefetch = 2
+
+	count := 0
+	for _, err := range c.All(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+		if count == 4 {
+			break
+		}
+	}
+	// page 1 and 2 are needed, prefetch may have asked for a few more but never all 100
+	if got := s.requests.Load(); got > 6 {
+		t.Errorf("server saw %d page requests for 4 users", got)
+	}
+}
+
+func TestAllStopsAtTheFirstError(t *testing.T) {
+	s := &standIn{total: 30, failPage: 3}
+	c := newTestClient(t, s)
+
+	var ids []int
+	var gotErr error
+	for user, err := range c.All(context.Background()) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		ids = append(ids, user.ID)
+	}
+	if client.StatusCode(gotErr) != http.StatusNotFound {
+		t.Fatalf("err = %v, want the 404 of page 3", gotErr)
+	}
+	if len(ids) != 6 {
+		t.Errorf("got users %v before the error, want the 6 of pages 1 and 2", ids)
+	}
+}
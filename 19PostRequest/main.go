@@ -2,37 +2,52 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
+	"os"
+
+	"github.com/SangamSilwal/httpkit/client"
 )
 
+// Course is the JSON we send, the field names become the keys
+type Course struct {
+	CourseName      string
+	Price           int
+	AvailableStatus bool
+}
+
+// CreatedCourse is what the server answers, only the fields we need
+type CreatedCourse struct {
+	Id string `json:"id"`
+	Course
+}
+
 func main() {
-	PerformPostJsonRequest()
+	if err := PerformPostJsonRequest(); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
 }
 
-func PerformPostJsonRequest() {
+func PerformPostJsonRequest() error {
 	const myurl = "SampleUrl"
-	requestBody := strings.NewReader(`
-	{
-	"CourseName":"Learn Goalang with sangam Silwal",
-	"Price":0,
-	"AvailableStatus":true
+	requestBody := Course{
+		CourseName:      "Learn Goalang with sangam Silwal",
+		Price:           0,
+		AvailableStatus: true,
 	}
-	`)
-	fmt.Println(requestBody)
-	fmt.Printf("\nThe dataTye of using .NewReader is : \n%T", requestBody) //The output: *strings.Reader
+	fmt.Printf("%+v\n", requestBody)
 
-	response, err := http.Post(myurl, "application/json", requestBody)
-	//The above is the syntax for performing Post in Golang
+	api, err := client.New(client.DefaultConfig())
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	defer response.Body.Close() // Closing the server using the defer
-
-	content, _ := io.ReadAll(requestBody)
-	fmt.Println(string(content))
-
+	// PostJSON marshals the struct, sets Content-Type and Accept,
+	// checks the status and decodes the response (not the request) into CreatedCourse
+	created, err := client.PostJSON[Course, CreatedCourse](context.Background(), api, myurl, requestBody)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created: %+v\n", created)
+	return nil
 }
@@ -0,0 +1,7 @@
+module formdata
+
+go 1.24.3
+
+require github.com/SangamSilwal/httpkit v0.0.0
+
+replace github.com/SangamSilwal/httpkit => ../httpkit
@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
+	"os"
+
+	"github.com/SangamSilwal/httpkit/client"
 )
 
 func main() {
-	sendFormData()
+	if err := sendFormData(); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
 }
 
-func sendFormData() {
+func sendFormData() error {
 	data := url.Values{}
 	data.Add("FirstName", "Sangam")
 	data.Add("LastName", "Silwal")
@@ -20,13 +26,20 @@ func sendFormData() {
 	fmt.Println(data)
 	fmt.Printf("The type of Data is : %T \n", data)
 
+	api, err := client.New(client.DefaultConfig())
+	if err != nil {
+		return err
+	}
 	//Using Post Form to post the data
-	response, err := http.PostForm("randomUrl", data)
+	response, err := api.PostForm(context.Background(), "randomUrl", data)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defer response.Body.Close()
-	content, _ := io.ReadAll(response.Body)
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
 	fmt.Println(string(content))
-
+	return nil
 }
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SangamSilwal/httpkit/problem"
+)
+
+// call sends one request through newRouter, tests set courses first
+func call(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeCourse(t *testing.T, rec *httptest.ResponseRecorder) Course {
+	t.Helper()
+	var c Course
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+		t.Fatalf("body %s: %v", rec.Body, err)
+	}
+	return c
+}
+
+func TestCreateAnswers201WithLocation(t *testing.T) {
+	courses = NewMemoryStore()
+	rec := call(t, http.MethodPost, "/courses", `{"coursename": "Go basics", "price": 100, "author": {"fullname": "Ana"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d %s, want 201", rec.Code, rec.Body)
+	}
+	created := decodeCourse(t, rec)
+	if created.CourseId == "" {
+		t.Fatal("no courseid assigned")
+	}
+	location := rec.Header().Get("Location")
+	if location != "/courses/"+created.CourseId {
+		t.Errorf("Location = %q, want /courses/%s", location, created.CourseId)
+	}
+
+	rec = call(t, http.MethodGet, location, "")
+	if rec.Code != http.StatusOK || decodeCourse(t, rec).CourseName != "Go basics" {
+		t.Errorf("GET Location = %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestUnknownCourseIs404Problem(t *testing.T) {
+	courses = NewMemoryStore()
+	for _, tt := range []struct{ method, body string }{
+		{http.MethodGet, ""},
+		{http.MethodPut, `{"coursename": "Go"}`},
+		{http.MethodPatch, `{"price": 1}`},
+		{http.MethodDelete, ""},
+	} {
+		rec := call(t, tt.method, "/courses/nope", tt.body)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s = %d, want 404", tt.method, rec.Code)
+			continue
+		}
+		if rec.Header().Get("Content-Type") != problem.ContentType {
+			t.Errorf("%s Content-Type = %q", tt.method, rec.Header().Get("Content-Type"))
+		}
+		var p problem.Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p.Status != http.StatusNotFound || p.Instance != "/courses/nope" {
+			t.Errorf("%s body = %s", tt.method, rec.Body)
+		}
+	}
+}
+
+func TestDuplicateNameIs409(t *testing.T) {
+	courses = NewMemoryStore()
+	call(t, http.MethodPost, "/courses", `{"coursename": "Go basics"}`)
+	other := decodeCourse(t, call(t, http.MethodPost, "/courses", `{"coursename": "Rust basics"}`))
+
+	if rec := call(t, http.MethodPost, "/courses", `{"coursename": "go BASICS"}`); rec.Code != http.StatusConflict {
+		t.Errorf("POST duplicate = %d, want 409", rec.Code)
+	}
+	if rec := call(t, http.MethodPut, "/courses/"+other.CourseId, `{"coursename": "Go basics"}`); rec.Code != http.StatusConflict {
+		t.Errorf("PUT to a taken name = %d, want 409", rec.Code)
+	}
+	if rec := call(t, http.MethodPatch, "/courses/"+other.CourseId, `{"coursename": "Go basics"}`); rec.Code != http.StatusConflict {
+		t.Errorf("PATCH to a taken name = %d, want 409", rec.Code)
+	}
+}
+
+func TestDeleteIs204(t *testing.T) {
+	courses = NewMemoryStore()
+	created := decodeCourse(t, call(t, http.MethodPost, "/courses", `{"coursename": "Go basics"}`))
+
+	rec := call(t, http.MethodDelete, "/courses/"+created.CourseId, "")
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("DELETE = %d with %d bytes, want 204 and no body", rec.Code, rec.Body.Len())
+	}
+	if rec := call(t, http.MethodGet, "/courses/"+created.CourseId, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET after DELETE = %d, want 404", rec.Code)
+	}
+}
+
+func TestPatchKeepsMissingFields(t *testing.T) {
+	courses = NewMemoryStore()
+	created := decodeCourse(t, call(t, http.MethodPost, "/courses",
+		`{"coursename": "Go basics", "price": 100, "author": {"fullname": "Ana", "website": "https://ana.example"}}`))
+
+	rec := call(t, http.MethodPatch, "/courses/"+created.CourseId, `{"price": 0}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH = %d %s", rec.Code, rec.Body)
+	}
+	want := created
+	want.CoursePrice = 0
+	got := decodeCourse(t, call(t, http.MethodGet, "/courses/"+created.CourseId, ""))
+	if got.CourseName != want.CourseName || got.CoursePrice != 0 || got.Author == nil || *got.Author != *want.Author {
+		t.Errorf("after PATCH = %+v, want %+v", got, want)
+	}
+
+	// PUT replaces the whole course, a missing author is removed
+	rec = call(t, http.MethodPut, "/courses/"+created.CourseId, `{"coursename": "Go basics", "price": 5}`)
+	if got := decodeCourse(t, rec); rec.Code != http.StatusOK || got.Author != nil || got.CourseId != created.CourseId {
+		t.Errorf("PUT = %d %+v", rec.Code, got)
+	}
+}
@@ -1,11 +1,25 @@
 package main
 
 import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/SangamSilwal/httpkit/metrics"
+	"github.com/SangamSilwal/httpkit/middleware"
+	"github.com/SangamSilwal/httpkit/problem"
+	"github.com/SangamSilwal/httpkit/ratelimit"
+	"github.com/SangamSilwal/httpkit/server"
 	"github.com/gorilla/mux"
 )
 
@@ -23,8 +37,18 @@ type Author struct {
 	Website  string `json:"website"`
 }
 
+// coursePatch holds the fields of a PATCH body, nil means "leave unchanged"
+type coursePatch struct {
+	CourseName  *string `json:"coursename"`
+	CoursePrice *int    `json:"price"`
+	Author      *Author `json:"author"`
+}
+
 // fake Db
-var courses []Course
+var courses CourseStore
+
+// httpMetrics is served on /metrics and fed by withMiddleware
+var httpMetrics = metrics.New()
 
 // middleware, helper - file
 func (c *Course) IsEmpty() bool {
@@ -32,59 +56,224 @@ func (c *Course) IsEmpty() bool {
 	return c.CourseName == ""
 }
 
+// newCourseId generates a random id, the client never chooses it
+func newCourseId() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
 //Controllers - file
 
 // server home route
 func serveHome(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("Served")
 	w.Write([]byte("<h1>Welcome to APi by Sangam Silwal</h1>"))
 
 }
 
+// writeStoreError maps the CourseStore errors to a problem response
+func writeStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrCourseNotFound):
+		problem.Error(w, r, http.StatusNotFound, "No course found with the given id")
+	case errors.Is(err, ErrDuplicateCourse):
+		problem.Error(w, r, http.StatusConflict, "A course with this name already exists")
+	default:
+		middleware.Logger(r.Context()).Error("store error", "error", err)
+		problem.Error(w, r, http.StatusInternalServerError, "Something went wrong")
+	}
+}
+
 func getAllCourses(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("Get all Courses")
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(courses)
+	q, err := parseCourseQuery(r.URL.Query())
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, err := courses.Find(q)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
 }
 
 func getOneCourse(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("Get one Course")
-	w.Header().Set("Content-Type", "application/json")
-
 	//Grabbing id from request
 	params := mux.Vars(r)
 
-	//loop through Courses, find matching id and return the response
-	for _, course := range courses {
-		if course.CourseId == params["id"] {
-			json.NewEncoder(w).Encode(course)
-			return
-		}
+	course, err := courses.Get(params["id"])
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
 	}
-	json.NewEncoder(w).Encode("No course found with the given id")
-	return
+	writeJSON(w, http.StatusOK, course)
 }
 
 func createOneCourse(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("Create one Course")
-	w.Header().Set("Content-Type", "application/json")
+	var course Course
+	if err := validated(decodeStrict(r, &course), course.Validate); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	course, err := courses.Create(course)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
 
-	if r.Body == nil {
-		json.NewEncoder(w).Encode("Please send some data")
+	w.Header().Set("Location", "/courses/"+course.CourseId)
+	writeJSON(w, http.StatusCreated, course)
+}
+
+// updateOneCourse replaces the whole course, the id stays the same
+func updateOneCourse(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	if _, err := courses.Get(params["id"]); err != nil {
+		writeStoreError(w, r, err)
+		return
 	}
 
 	var course Course
-	_ = json.NewDecoder(r.Body).Decode(&course)
-	if course.IsEmpty() {
-		json.NewEncoder(w).Encode("Empty")
+	if err := validated(decodeStrict(r, &course), course.Validate); err != nil {
+		writeDecodeError(w, r, err)
 		return
 	}
 
+	course.CourseId = params["id"]
+	course, err := courses.Update(course)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, course)
 }
 
-func main() {
-	fmt.Println("Running New Server")
+// patchOneCourse only changes the fields present in the body
+func patchOneCourse(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	course, err := courses.Get(params["id"])
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	var patch coursePatch
+	decodeErr := decodeStrict(r, &patch)
+
+	if patch.CourseName != nil {
+		course.CourseName = *patch.CourseName
+	}
+	if patch.CoursePrice != nil {
+		course.CoursePrice = *patch.CoursePrice
+	}
+	if patch.Author != nil {
+		course.Author = patch.Author
+	}
+	if err := validated(decodeErr, course.Validate); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	course, err = courses.Update(course)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, course)
+}
+
+func deleteOneCourse(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	if err := courses.Delete(params["id"]); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// openStore picks the CourseStore implementation at startup,
+// an empty dataFile means the default file of that store
+func openStore(kind, dataFile string) (CourseStore, error) {
+	switch kind {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "file":
+		return NewFileStore(cmp.Or(dataFile, "courses.json"))
+	case "sqlite":
+		return NewSQLiteStore(cmp.Or(dataFile, "courses.db"))
+	default:
+		return nil, fmt.Errorf("unknown store %q, use memory, file or sqlite", kind)
+	}
+}
+
+// newRouter registers every route, every route must also be described in openapi.go
+func newRouter() *mux.Router {
 	r := mux.NewRouter()
-	r.HandleFunc("/", serveHome)
-	log.Fatal(http.ListenAndServe(":8000", r))
+	r.NotFoundHandler = problem.NotFoundHandler()
+	r.MethodNotAllowedHandler = problem.MethodNotAllowedHandler()
+
+	//Routing
+	r.HandleFunc("/", serveHome).Methods("GET")
+	r.HandleFunc("/openapi.json", serveOpenAPI(r)).Methods("GET")
+	r.Handle("/metrics", httpMetrics.Handler()).Methods("GET")
+	r.HandleFunc("/courses", getAllCourses).Methods("GET")
+	r.HandleFunc("/courses", createOneCourse).Methods("POST")
+	r.HandleFunc("/courses/{id}", getOneCourse).Methods("GET")
+	r.HandleFunc("/courses/{id}", updateOneCourse).Methods("PUT")
+	r.HandleFunc("/courses/{id}", patchOneCourse).Methods("PATCH")
+	r.HandleFunc("/courses/{id}", deleteOneCourse).Methods("DELETE")
+	return r
+}
+
+func main() {
+	cfg := server.DefaultConfig("8000")
+	cfg.RegisterFlags(flag.CommandLine)
+	storeKind := flag.String("store", "memory", "where to keep courses: memory, file or sqlite")
+	dataFile := flag.String("data", "", "data file used by the file and sqlite stores, default courses.json or courses.db")
+	readLimit := flag.Int("rate-limit", 120, "GET requests per minute per client IP, 0 disables")
+	writeLimit := flag.Int("write-rate-limit", 30, "POST, PUT, PATCH and DELETE requests per minute per client IP, 0 disables")
+	httpCfg := httpConfig{
+		cors:     middleware.DefaultCORSConfig(),
+		security: middleware.DefaultSecurityConfig(),
+	}
+	httpCfg.cors.RegisterFlags(flag.CommandLine)
+	httpCfg.security.RegisterFlags(flag.CommandLine)
+	flag.Int64Var(&httpCfg.maxBodyBytes, "max-body-bytes", middleware.DefaultMaxBodyBytes, "largest request body accepted, bigger ones get 413")
+	flag.Parse()
+
+	store, err := openStore(*storeKind, *dataFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	courses = store
+	if closer, ok := store.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	logger.Info("Running New Server", "store", *storeKind)
+	r := newRouter()
+	r.Use(rateLimit(
+		ratelimit.New(ratelimit.Config{Requests: *readLimit, Per: time.Minute}),
+		ratelimit.New(ratelimit.Config{Requests: *writeLimit, Per: time.Minute}),
+	))
+
+	if err := server.Run(cfg, withMiddleware(r, logger, httpCfg)); err != nil {
+		logger.Error("server stopped", "error", err)
+	}
 }
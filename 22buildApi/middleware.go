@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/SangamSilwal/httpkit/metrics"
+	"github.com/SangamSilwal/httpkit/middleware"
+	"github.com/SangamSilwal/httpkit/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+// httpConfig is what main configures for withMiddleware
+type httpConfig struct {
+	cors         middleware.CORSConfig
+	security     middleware.SecurityConfig
+	maxBodyBytes int64
+}
+
+// withMiddleware wraps the whole router, not r.Use, so unmatched routes
+// also get a request id and an access log line, and preflights for any path are answered
+// order: request id -> metrics -> access log -> security headers -> cors -> recover -> body limit -> router
+func withMiddleware(r *mux.Router, logger *slog.Logger, cfg httpConfig) http.Handler {
+	var h http.Handler = r
+	h = middleware.BodyLimit(cfg.maxBodyBytes)(h)
+	h = middleware.Recover(logger)(h)
+	h = middleware.CORS(cfg.cors)(h)
+	h = middleware.SecurityHeaders(cfg.security)(h)
+	h = middleware.AccessLog(logger, routeTemplate(r))(h)
+	h = httpMetrics.Middleware(routeTemplate(r))(h)
+	h = middleware.RequestID(h)
+	return h
+}
+
+// routeTemplate looks up the mux route template for a request,
+// mux.CurrentRoute is only set inside the router so we match again here
+func routeTemplate(router *mux.Router) middleware.RouteFunc {
+	return func(r *http.Request) string {
+		var match mux.RouteMatch
+		if !router.Match(r, &match) || match.Route == nil {
+			return metrics.Unmatched
+		}
+		template, err := match.Route.GetPathTemplate()
+		if err != nil {
+			return metrics.Unmatched
+		}
+		return template
+	}
+}
+
+// rateLimit is added with r.Use, reads and writes are separate groups
+// with their own buckets, a client spending its write budget can still read
+func rateLimit(read, write *ratelimit.Limiter) mux.MiddlewareFunc {
+	readMW := ratelimit.Middleware(read, ratelimit.ByIP)
+	writeMW := ratelimit.Middleware(write, ratelimit.ByIP)
+	return func(next http.Handler) http.Handler {
+		reads, writes := readMW(next), writeMW(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				reads.ServeHTTP(w, r)
+			default:
+				writes.ServeHTTP(w, r)
+			}
+		})
+	}
+}
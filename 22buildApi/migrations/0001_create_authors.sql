@@ -0,0 +1,6 @@
+CREATE TABLE authors (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	fullname TEXT NOT NULL,
+	website  TEXT NOT NULL DEFAULT '',
+	UNIQUE (fullname, website)
+);
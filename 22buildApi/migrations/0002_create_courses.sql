@@ -0,0 +1,9 @@
+CREATE TABLE courses (
+	id         TEXT PRIMARY KEY,
+	coursename TEXT NOT NULL COLLATE NOCASE UNIQUE,
+	price      INTEGER NOT NULL DEFAULT 0,
+	author_id  INTEGER REFERENCES authors (id),
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX courses_author_id ON courses (author_id);
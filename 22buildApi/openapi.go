@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/SangamSilwal/httpkit/problem"
+	"github.com/gorilla/mux"
+)
+
+// paramDoc describes a query parameter
+type paramDoc struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+type responseDoc struct {
+	Description string
+	// Schema names a component built from a Go type, empty means no body
+	Schema string
+}
+
+// operationDoc describes one METHOD + route template
+type operationDoc struct {
+	Summary     string
+	OperationId string
+	Query       []paramDoc
+	// Request names the component used as JSON request body
+	Request   string
+	Responses map[int]responseDoc
+}
+
+// componentTypes are the Go types exposed under components/schemas
+var componentTypes = map[string]reflect.Type{
+	"Course":      reflect.TypeOf(Course{}),
+	"Author":      reflect.TypeOf(Author{}),
+	"CoursePatch": reflect.TypeOf(coursePatch{}),
+	"CoursePage":  reflect.TypeOf(CoursePage{}),
+	"Problem":     reflect.TypeOf(problem.Problem{}),
+	"FieldError":  reflect.TypeOf(problem.FieldError{}),
+}
+
+// requiredFields lists what Validate insists on, keyed by component name
+var requiredFields = map[string][]string{
+	"Course": {"coursename"},
+	"Author": {"fullname"},
+}
+
+var (
+	notFound      = responseDoc{"No course with this id", "Problem"}
+	badRequest    = responseDoc{"Body or query is malformed", "Problem"}
+	invalidCourse = responseDoc{"One or more fields are invalid", "Problem"}
+	duplicateName = responseDoc{"Another course already uses this name", "Problem"}
+)
+
+// routeDocs describes every route registered in newRouter, keyed by "METHOD template"
+var routeDocs = map[string]operationDoc{
+	"GET /": {
+		Summary:     "Welcome page",
+		OperationId: "serveHome",
+		Responses:   map[int]responseDoc{200: {"HTML welcome page", ""}},
+	},
+	"GET /openapi.json": {
+		Summary:     "This OpenAPI document",
+		OperationId: "getOpenAPI",
+		Responses:   map[int]responseDoc{200: {"OpenAPI 3 document", ""}},
+	},
+	"GET /metrics": {
+		Summary:     "Prometheus metrics of this service",
+		OperationId: "getMetrics",
+		Responses:   map[int]responseDoc{200: {"Metrics in the Prometheus text format", ""}},
+	},
+	"GET /courses": {
+		Summary:     "List courses, one page at a time",
+		OperationId: "getAllCourses",
+		Query: []paramDoc{
+			{"limit", "integer", fmt.Sprintf("page size, 1 to %d, default %d", maxPageSize, defaultPageSize)},
+			{"cursor", "string", "next_cursor of the previous page"},
+			{"sort", "string", "comma separated fields, - prefix for descending, e.g. price,-coursename"},
+			{"author", "string", "author fullname, case insensitive"},
+			{"minPrice", "integer", "lowest price included"},
+			{"maxPrice", "integer", "highest price included"},
+		},
+		Responses: map[int]responseDoc{200: {"One page of courses", "CoursePage"}, 400: badRequest},
+	},
+	"POST /courses": {
+		Summary:     "Create a course, the server picks the courseid",
+		OperationId: "createOneCourse",
+		Request:     "Course",
+		Responses: map[int]responseDoc{
+			201: {"Created, Location points to the new course", "Course"},
+			400: badRequest, 409: duplicateName, 422: invalidCourse,
+		},
+	},
+	"GET /courses/{id}": {
+		Summary:     "Get one course",
+		OperationId: "getOneCourse",
+		Responses:   map[int]responseDoc{200: {"The course", "Course"}, 404: notFound},
+	},
+	"PUT /courses/{id}": {
+		Summary:     "Replace a course",
+		OperationId: "updateOneCourse",
+		Request:     "Course",
+		Responses: map[int]responseDoc{
+			200: {"The updated course", "Course"},
+			400: badRequest, 404: notFound, 409: duplicateName, 422: invalidCourse,
+		},
+	},
+	"PATCH /courses/{id}": {
+		Summary:     "Change some fields of a course",
+		OperationId: "patchOneCourse",
+		Request:     "CoursePatch",
+		Responses: map[int]responseDoc{
+			200: {"The updated course", "Course"},
+			400: badRequest, 404: notFound, 409: duplicateName, 422: invalidCourse,
+		},
+	},
+	"DELETE /courses/{id}": {
+		Summary:     "Delete a course",
+		OperationId: "deleteOneCourse",
+		Responses:   map[int]responseDoc{204: {"Deleted", ""}, 404: notFound},
+	},
+}
+
+// routeKey is how routeDocs is indexed
+func routeKey(method, template string) string {
+	return method + " " + template
+}
+
+// registeredRoutes walks the router and returns every "METHOD template" key
+func registeredRoutes(r *mux.Router) ([]string, error) {
+	var keys []string
+	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return fmt.Errorf("route %s has no methods", template)
+		}
+		for _, m := range methods {
+			keys = append(keys, routeKey(m, template))
+		}
+		return nil
+	})
+	return keys, err
+}
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+
+// buildOpenAPI turns the registered routes and routeDocs into an OpenAPI 3 document
+// routes without docs still show up, with a summary saying so
+func buildOpenAPI(r *mux.Router) (map[string]any, error) {
+	keys, err := registeredRoutes(r)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[string]map[string]any{}
+	for _, key := range keys {
+		method, template, _ := strings.Cut(key, " ")
+		doc, ok := routeDocs[key]
+		if !ok {
+			doc = operationDoc{Summary: "Undocumented"}
+		}
+
+		// mux templates may carry a regexp, {id:[0-9]+}, OpenAPI only wants {id}
+		openapiPath := pathParam.ReplaceAllString(template, "{$1}")
+		if paths[openapiPath] == nil {
+			paths[openapiPath] = map[string]any{}
+		}
+		paths[openapiPath][strings.ToLower(method)] = buildOperation(doc, template)
+	}
+
+	schemas := map[string]any{}
+	for name, t := range componentTypes {
+		schemas[name] = objectSchema(t, requiredFields[name])
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Course API",
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}, nil
+}
+
+func buildOperation(doc operationDoc, template string) map[string]any {
+	op := map[string]any{"summary": doc.Summary}
+	if doc.OperationId != "" {
+		op["operationId"] = doc.OperationId
+	}
+
+	var params []any
+	for _, m := range pathParam.FindAllStringSubmatch(template, -1) {
+		params = append(params, map[string]any{
+			"name": m[1], "in": "path", "required": true,
+			"schema": map[string]any{"type": "string"},
+		})
+	}
+	for _, q := range doc.Query {
+		params = append(params, map[string]any{
+			"name": q.Name, "in": "query", "description": q.Description,
+			"schema": map[string]any{"type": q.Type},
+		})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if doc.Request != "" {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaRef(doc.Request)},
+			},
+		}
+	}
+
+	responses := map[string]any{}
+	for status, res := range doc.Responses {
+		body := map[string]any{"description": res.Description}
+		if res.Schema != "" {
+			contentType := "application/json"
+			if res.Schema == "Problem" {
+				contentType = problem.ContentType
+			}
+			body["content"] = map[string]any{contentType: map[string]any{"schema": schemaRef(res.Schema)}}
+		}
+		responses[strconv.Itoa(status)] = body
+	}
+	if len(responses) == 0 {
+		responses["default"] = map[string]any{"description": "Undocumented"}
+	}
+	op["responses"] = responses
+	return op
+}
+
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// objectSchema reflects a struct, property names come from the json tags
+func objectSchema(t reflect.Type, required []string) map[string]any {
+	props := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = typeSchema(f.Type)
+	}
+
+	schema := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// typeSchema maps a Go type to a schema, named structs become a $ref
+func typeSchema(t reflect.Type) map[string]any {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema map[string]any
+	switch t.Kind() {
+	case reflect.String:
+		schema = map[string]any{"type": "string"}
+	case reflect.Bool:
+		schema = map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema = map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		schema = map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		schema = map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Struct:
+		for name, ct := range componentTypes {
+			if ct == t {
+				if nullable {
+					return map[string]any{"allOf": []any{schemaRef(name)}, "nullable": true}
+				}
+				return schemaRef(name)
+			}
+		}
+		schema = objectSchema(t, nil)
+	default:
+		schema = map[string]any{}
+	}
+	if nullable {
+		schema["nullable"] = true
+	}
+	return schema
+}
+
+// serveOpenAPI answers GET /openapi.json with the document for router r
+func serveOpenAPI(r *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		spec, err := buildOpenAPI(r)
+		if err != nil {
+			problem.Error(w, req, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, spec)
+	}
+}
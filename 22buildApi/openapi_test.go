@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestEveryRouteIsDocumented fails when newRouter gains a route that routeDocs does not describe
+func TestEveryRouteIsDocumented(t *testing.T) {
+	keys, err := registeredRoutes(newRouter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) == 0 {
+		t.Fatal("router has no routes")
+	}
+
+	registered := map[string]bool{}
+	for _, key := range keys {
+		registered[key] = true
+		if _, ok := routeDocs[key]; !ok {
+			t.Errorf("route %q is not described in routeDocs", key)
+		}
+	}
+	for key := range routeDocs {
+		if !registered[key] {
+			t.Errorf("routeDocs describes %q but no such route is registered", key)
+		}
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var spec struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+			}
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q", spec.OpenAPI)
+	}
+	if _, ok := spec.Paths["/courses/{id}"]["patch"]; !ok {
+		t.Error("PATCH /courses/{id} missing from paths")
+	}
+	// property names must follow the json tags, not the Go field names
+	course := spec.Components.Schemas["Course"].Properties
+	for _, name := range []string{"courseid", "coursename", "price", "author"} {
+		if _, ok := course[name]; !ok {
+			t.Errorf("Course schema has no %q property", name)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// sortable fields, keyed by the json name the client uses
+var sortFields = map[string]bool{
+	"coursename": true,
+	"price":      true,
+	"courseid":   true,
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// CourseQuery is a parsed GET /courses request
+type CourseQuery struct {
+	Limit    int
+	Cursor   *courseCursor
+	Sort     []SortField
+	Author   string
+	MinPrice *int
+	MaxPrice *int
+}
+
+// CoursePage is the response envelope of GET /courses
+type CoursePage struct {
+	Data       []Course `json:"data"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	Total      int      `json:"total"`
+}
+
+// courseCursor remembers the sort key of the last course on a page,
+// the next page starts right after it
+type courseCursor struct {
+	CourseId    string `json:"i"`
+	CourseName  string `json:"n"`
+	CoursePrice int    `json:"p"`
+}
+
+func (c courseCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*courseCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c courseCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func cursorFor(course Course) *courseCursor {
+	return &courseCursor{CourseId: course.CourseId, CourseName: course.CourseName, CoursePrice: course.CoursePrice}
+}
+
+// parseCourseQuery reads ?limit=&cursor=&sort=&author=&minPrice=&maxPrice=
+func parseCourseQuery(values url.Values) (CourseQuery, error) {
+	q := CourseQuery{Limit: defaultPageSize, Author: values.Get("author")}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return q, fmt.Errorf("limit must be a number between 1 and %d", maxPageSize)
+		}
+		q.Limit = limit
+	}
+
+	if v := values.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return q, fmt.Errorf("cursor is not valid")
+		}
+		q.Cursor = cursor
+	}
+
+	// ?sort=price,-coursename, a leading - means descending
+	if v := values.Get("sort"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			field := SortField{Field: strings.TrimSpace(part)}
+			if strings.HasPrefix(field.Field, "-") {
+				field.Desc = true
+				field.Field = field.Field[1:]
+			}
+			if !sortFields[field.Field] {
+				return q, fmt.Errorf("cannot sort by %q", field.Field)
+			}
+			q.Sort = append(q.Sort, field)
+		}
+	}
+
+	var err error
+	if q.MinPrice, err = parsePrice(values, "minPrice"); err != nil {
+		return q, err
+	}
+	if q.MaxPrice, err = parsePrice(values, "maxPrice"); err != nil {
+		return q, err
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MinPrice > *q.MaxPrice {
+		return q, fmt.Errorf("minPrice cannot be greater than maxPrice")
+	}
+	return q, nil
+}
+
+func parsePrice(values url.Values, key string) (*int, error) {
+	v := values.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+	price, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", key)
+	}
+	return &price, nil
+}
+
+// orderBy is the requested sort with courseid appended as a tie breaker,
+// so every course has a unique position and the cursor is stable
+func (q CourseQuery) orderBy() []SortField {
+	order := append([]SortField{}, q.Sort...)
+	for _, f := range order {
+		if f.Field == "courseid" {
+			return order
+		}
+	}
+	return append(order, SortField{Field: "courseid"})
+}
+
+// matches applies the author and price filters
+func (q CourseQuery) matches(course Course) bool {
+	if q.Author != "" && (course.Author == nil || compareNoCase(course.Author.Fullname, q.Author) != 0) {
+		return false
+	}
+	if q.MinPrice != nil && course.CoursePrice < *q.MinPrice {
+		return false
+	}
+	if q.MaxPrice != nil && course.CoursePrice > *q.MaxPrice {
+		return false
+	}
+	return true
+}
+
+// compareCourses orders a before b (-1), after b (1) or equal (0) following order
+func compareCourses(a, b courseCursor, order []SortField) int {
+	for _, f := range order {
+		var c int
+		switch f.Field {
+		case "coursename":
+			c = compareNoCase(a.CourseName, b.CourseName)
+		case "price":
+			c = a.CoursePrice - b.CoursePrice
+		case "courseid":
+			c = strings.Compare(a.CourseId, b.CourseId)
+		}
+		if c != 0 {
+			if f.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// compareNoCase orders strings like SQLite's NOCASE collation, which folds
+// only ASCII letters, so every store sorts and matches names the same way
+func compareNoCase(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := cmp.Compare(lowerASCII(a[i]), lowerASCII(b[i])); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// pageCourses filters, sorts and pages an in-memory list
+func pageCourses(list []Course, q CourseQuery) CoursePage {
+	order := q.orderBy()
+
+	matched := []Course{}
+	for _, course := range list {
+		if q.matches(course) {
+			matched = append(matched, course)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return compareCourses(*cursorFor(matched[i]), *cursorFor(matched[j]), order) < 0
+	})
+
+	page := CoursePage{Total: len(matched), Data: []Course{}}
+	start := 0
+	if q.Cursor != nil {
+		start = sort.Search(len(matched), func(i int) bool {
+			return compareCourses(*cursorFor(matched[i]), *q.Cursor, order) > 0
+		})
+	}
+	end := min(start+q.Limit, len(matched))
+	page.Data = append(page.Data, matched[start:end]...)
+	if end < len(matched) {
+		page.NextCursor = cursorFor(matched[end-1]).encode()
+	}
+	return page
+}
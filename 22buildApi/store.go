@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Errors returned by every CourseStore so the controllers can map them to status codes
+var (
+	ErrCourseNotFound  = errors.New("course not found")
+	ErrDuplicateCourse = errors.New("a course with this name already exists")
+)
+
+// CourseStore is where the courses live, the controllers only talk to this
+type CourseStore interface {
+	List() ([]Course, error)
+	// Find returns one filtered and sorted page of courses
+	Find(q CourseQuery) (CoursePage, error)
+	Get(id string) (Course, error)
+	// Create assigns a new CourseId and returns the stored course
+	Create(course Course) (Course, error)
+	// Update replaces the course with the same CourseId
+	Update(course Course) (Course, error)
+	Delete(id string) error
+}
+
+// memoryStore keeps courses in a map indexed by CourseId
+// order remembers insertion order so List is stable
+type memoryStore struct {
+	mu      sync.RWMutex
+	courses map[string]Course
+	order   []string
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{courses: make(map[string]Course)}
+}
+
+func (s *memoryStore) List() ([]Course, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Course, 0, len(s.order))
+	for _, id := range s.order {
+		list = append(list, s.courses[id])
+	}
+	return list, nil
+}
+
+func (s *memoryStore) Find(q CourseQuery) (CoursePage, error) {
+	list, err := s.List()
+	if err != nil {
+		return CoursePage{}, err
+	}
+	return pageCourses(list, q), nil
+}
+
+func (s *memoryStore) Get(id string) (Course, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	course, ok := s.courses[id]
+	if !ok {
+		return Course{}, ErrCourseNotFound
+	}
+	return course, nil
+}
+
+func (s *memoryStore) Create(course Course) (Course, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nameTaken(course.CourseName, "") {
+		return Course{}, ErrDuplicateCourse
+	}
+	course.CourseId = newCourseId()
+	s.put(course)
+	return course, nil
+}
+
+func (s *memoryStore) Update(course Course) (Course, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.courses[course.CourseId]; !ok {
+		return Course{}, ErrCourseNotFound
+	}
+	if s.nameTaken(course.CourseName, course.CourseId) {
+		return Course{}, ErrDuplicateCourse
+	}
+	s.courses[course.CourseId] = course
+	return course, nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.courses[id]; !ok {
+		return ErrCourseNotFound
+	}
+	delete(s.courses, id)
+	for i, v := range s.order {
+		if v == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// put inserts or replaces a course, callers must hold the lock
+func (s *memoryStore) put(course Course) {
+	if _, ok := s.courses[course.CourseId]; !ok {
+		s.order = append(s.order, course.CourseId)
+	}
+	s.courses[course.CourseId] = course
+}
+
+// nameTaken reports whether another course (not skipId) already uses the name
+func (s *memoryStore) nameTaken(name, skipId string) bool {
+	for id, course := range s.courses {
+		if id != skipId && compareNoCase(course.CourseName, name) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryState is a copy of everything in a memoryStore
+type memoryState struct {
+	courses map[string]Course
+	order   []string
+}
+
+func (s *memoryStore) state() memoryState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return memoryState{courses: maps.Clone(s.courses), order: slices.Clone(s.order)}
+}
+
+// restore puts back a state taken earlier
+func (s *memoryStore) restore(st memoryState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.courses, s.order = st.courses, st.order
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/SangamSilwal/httpkit/fileutil"
+)
+
+// fileStore keeps the courses in memory and writes a JSON snapshot
+// to disk after every change, so the data survives a restart
+type fileStore struct {
+	*memoryStore
+	path string
+	// writeMu makes sure snapshots hit the disk in the same order as the changes
+	writeMu sync.Mutex
+}
+
+// NewFileStore loads the courses from path, a missing file means an empty store
+func NewFileStore(path string) (*fileStore, error) {
+	s := &fileStore{memoryStore: NewMemoryStore(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved []Course
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return nil, err
+		}
+	}
+	for _, course := range saved {
+		s.put(course)
+	}
+	return s, nil
+}
+
+func (s *fileStore) Create(course Course) (Course, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	before := s.state()
+	course, err := s.memoryStore.Create(course)
+	if err != nil {
+		return Course{}, err
+	}
+	if err := s.commit(before); err != nil {
+		return Course{}, err
+	}
+	return course, nil
+}
+
+func (s *fileStore) Update(course Course) (Course, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	before := s.state()
+	course, err := s.memoryStore.Update(course)
+	if err != nil {
+		return Course{}, err
+	}
+	if err := s.commit(before); err != nil {
+		return Course{}, err
+	}
+	return course, nil
+}
+
+func (s *fileStore) Delete(id string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	before := s.state()
+	if err := s.memoryStore.Delete(id); err != nil {
+		return err
+	}
+	return s.commit(before)
+}
+
+// commit saves the change just made in memory, when the file cannot be
+// written the memory goes back to before, so memory never has what disk lacks
+func (s *fileStore) commit(before memoryState) error {
+	if err := s.save(); err != nil {
+		s.restore(before)
+		return err
+	}
+	return nil
+}
+
+// save replaces the JSON file with the current courses
+func (s *fileStore) save() error {
+	list, err := s.List()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(list, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return fileutil.WriteFileAtomic(s.path, data, 0o600)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// sqliteStore keeps courses and authors in two tables of a SQLite file
+// modernc.org/sqlite is pure Go, so no cgo or database server is needed
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens the database at path and applies pending migrations
+func NewSQLiteStore(path string) (*sqliteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+// migrate runs every file in migrations/ that is not yet recorded in schema_migrations
+// files run in name order, each one inside its own transaction
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var applied int
+		err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, name).Scan(&applied)
+		if err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		err = withTx(db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(string(script)); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// withTx commits when fn returns nil and rolls back otherwise
+func withTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+const selectCourse = `SELECT c.id, c.coursename, c.price, a.fullname, a.website
+	FROM courses c LEFT JOIN authors a ON a.id = c.author_id`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCourse(row rowScanner) (Course, error) {
+	var course Course
+	var fullname, website sql.NullString
+	if err := row.Scan(&course.CourseId, &course.CourseName, &course.CoursePrice, &fullname, &website); err != nil {
+		return Course{}, err
+	}
+	if fullname.Valid {
+		course.Author = &Author{Fullname: fullname.String, Website: website.String}
+	}
+	return course, nil
+}
+
+func (s *sqliteStore) List() ([]Course, error) {
+	rows, err := s.db.Query(selectCourse + ` ORDER BY c.rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Course{}
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, course)
+	}
+	return list, rows.Err()
+}
+
+// sortColumns maps the json sort names to columns
+var sortColumns = map[string]string{
+	"coursename": "c.coursename",
+	"price":      "c.price",
+	"courseid":   "c.id",
+}
+
+// Find filters in SQL and pages with a keyset on the sort columns,
+// so deep pages cost the same as the first one
+func (s *sqliteStore) Find(q CourseQuery) (CoursePage, error) {
+	var where []string
+	var args []any
+	if q.Author != "" {
+		where = append(where, `a.fullname = ? COLLATE NOCASE`)
+		args = append(args, q.Author)
+	}
+	if q.MinPrice != nil {
+		where = append(where, `c.price >= ?`)
+		args = append(args, *q.MinPrice)
+	}
+	if q.MaxPrice != nil {
+		where = append(where, `c.price <= ?`)
+		args = append(args, *q.MaxPrice)
+	}
+
+	from := ` FROM courses c LEFT JOIN authors a ON a.id = c.author_id`
+	filter := ""
+	if len(where) > 0 {
+		filter = ` WHERE ` + strings.Join(where, ` AND `)
+	}
+
+	page := CoursePage{Data: []Course{}}
+	if err := s.db.QueryRow(`SELECT COUNT(*)`+from+filter, args...).Scan(&page.Total); err != nil {
+		return CoursePage{}, err
+	}
+
+	order := q.orderBy()
+	if q.Cursor != nil {
+		after, afterArgs := keysetAfter(order, *q.Cursor)
+		where = append(where, after)
+		args = append(args, afterArgs...)
+	}
+	var orderBy []string
+	for _, f := range order {
+		col := sortColumns[f.Field]
+		if f.Desc {
+			col += ` DESC`
+		}
+		orderBy = append(orderBy, col)
+	}
+
+	query := selectCourse
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY ` + strings.Join(orderBy, `, `) + ` LIMIT ?`
+	// one extra row tells us whether there is a next page
+	args = append(args, q.Limit+1)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return CoursePage{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return CoursePage{}, err
+		}
+		page.Data = append(page.Data, course)
+	}
+	if err := rows.Err(); err != nil {
+		return CoursePage{}, err
+	}
+
+	if len(page.Data) > q.Limit {
+		page.Data = page.Data[:q.Limit]
+		page.NextCursor = cursorFor(page.Data[q.Limit-1]).encode()
+	}
+	return page, nil
+}
+
+// keysetAfter builds "row comes after cursor" for a mixed asc/desc order:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+func keysetAfter(order []SortField, cursor courseCursor) (string, []any) {
+	values := map[string]any{
+		"coursename": cursor.CourseName,
+		"price":      cursor.CoursePrice,
+		"courseid":   cursor.CourseId,
+	}
+
+	var ors []string
+	var args []any
+	for i, f := range order {
+		var ands []string
+		for _, prev := range order[:i] {
+			ands = append(ands, sortColumns[prev.Field]+` = ?`)
+			args = append(args, values[prev.Field])
+		}
+		op := ` > ?`
+		if f.Desc {
+			op = ` < ?`
+		}
+		ands = append(ands, sortColumns[f.Field]+op)
+		args = append(args, values[f.Field])
+		ors = append(ors, `(`+strings.Join(ands, ` AND `)+`)`)
+	}
+	return `(` + strings.Join(ors, ` OR `) + `)`, args
+}
+
+func (s *sqliteStore) Get(id string) (Course, error) {
+	course, err := scanCourse(s.db.QueryRow(selectCourse+` WHERE c.id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Course{}, ErrCourseNotFound
+	}
+	return course, err
+}
+
+// Create writes the author and the course in one transaction
+func (s *sqliteStore) Create(course Course) (Course, error) {
+	course.CourseId = newCourseId()
+	err := withTx(s.db, func(tx *sql.Tx) error {
+		authorId, err := upsertAuthor(tx, course.Author)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO courses (id, coursename, price, author_id) VALUES (?, ?, ?, ?)`,
+			course.CourseId, course.CourseName, course.CoursePrice, authorId)
+		return err
+	})
+	if err != nil {
+		return Course{}, mapSQLiteError(err)
+	}
+	return course, nil
+}
+
+// Update writes the author and the course in one transaction
+func (s *sqliteStore) Update(course Course) (Course, error) {
+	err := withTx(s.db, func(tx *sql.Tx) error {
+		authorId, err := upsertAuthor(tx, course.Author)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(`UPDATE courses SET coursename = ?, price = ?, author_id = ? WHERE id = ?`,
+			course.CourseName, course.CoursePrice, authorId, course.CourseId)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrCourseNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return Course{}, mapSQLiteError(err)
+	}
+	return course, nil
+}
+
+func (s *sqliteStore) Delete(id string) error {
+	res, err := s.db.Exec(`DELETE FROM courses WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCourseNotFound
+	}
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// upsertAuthor returns the id of the matching author row, inserting it when missing
+// a nil author gives a NULL author_id
+func upsertAuthor(tx *sql.Tx, author *Author) (sql.NullInt64, error) {
+	if author == nil {
+		return sql.NullInt64{}, nil
+	}
+	_, err := tx.Exec(`INSERT INTO authors (fullname, website) VALUES (?, ?)
+		ON CONFLICT (fullname, website) DO NOTHING`, author.Fullname, author.Website)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	var id int64
+	err = tx.QueryRow(`SELECT id FROM authors WHERE fullname = ? AND website = ?`,
+		author.Fullname, author.Website).Scan(&id)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	return sql.NullInt64{Int64: id, Valid: true}, nil
+}
+
+// mapSQLiteError turns the unique constraint on coursename into ErrDuplicateCourse
+func mapSQLiteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return ErrDuplicateCourse
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testStores returns one of every CourseStore, the file and sqlite ones in a temp dir
+func testStores(t *testing.T) map[string]CourseStore {
+	t.Helper()
+	dir := t.TempDir()
+	file, err := NewFileStore(filepath.Join(dir, "courses.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewSQLiteStore(filepath.Join(dir, "courses.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return map[string]CourseStore{"memory": NewMemoryStore(), "file": file, "sqlite": db}
+}
+
+// seedCourses mixes ASCII case, non-ASCII case and equal prices,
+// NOCASE folds A-Z only so Éclair and éclair are two different names
+func seedCourses(t *testing.T, store CourseStore) {
+	t.Helper()
+	ana := &Author{Fullname: "Ana"}
+	bob := &Author{Fullname: "Bob"}
+	for _, c := range []Course{
+		{CourseName: "Go basics", CoursePrice: 100, Author: ana},
+		{CourseName: "go advanced", CoursePrice: 100, Author: &Author{Fullname: "ana"}},
+		{CourseName: "Algorithms", CoursePrice: 100, Author: bob},
+		{CourseName: "Éclair baking", CoursePrice: 50, Author: ana},
+		{CourseName: "éclair baking", CoursePrice: 50},
+		{CourseName: "Zebra", CoursePrice: 50, Author: &Author{Fullname: "Éva"}},
+		{CourseName: "apple", CoursePrice: 200, Author: ana},
+		{CourseName: "Banana", CoursePrice: 200},
+		{CourseName: "[brackets]", CoursePrice: 150, Author: ana},
+	} {
+		if _, err := store.Create(c); err != nil {
+			t.Fatalf("create %q: %v", c.CourseName, err)
+		}
+	}
+}
+
+// walk follows next_cursor until the last page and returns the names in order
+func walk(t *testing.T, store CourseStore, values url.Values) []string {
+	t.Helper()
+	q, err := parseCourseQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("paging does not end")
+		}
+		page, err := store.Find(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range page.Data {
+			names = append(names, c.CourseName)
+		}
+		if page.NextCursor == "" {
+			if len(names) != page.Total {
+				t.Errorf("walked %d courses, total says %d", len(names), page.Total)
+			}
+			return names
+		}
+		if q.Cursor, err = decodeCursor(page.NextCursor); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestStoresPageTheSameWay walks every page of both store kinds,
+// memory sorting must agree with the NOCASE column in SQLite
+func TestStoresPageTheSameWay(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"sort=price,-coursename&limit=2", []string{
+			"éclair baking", "Éclair baking", "Zebra",
+			"Go basics", "go advanced", "Algorithms",
+			"[brackets]",
+			"Banana", "apple",
+		}},
+		{"sort=coursename&limit=4", []string{
+			"[brackets]", "Algorithms", "apple", "Banana", "go advanced",
+			"Go basics", "Zebra", "Éclair baking", "éclair baking",
+		}},
+		{"sort=price,-coursename&minPrice=100&author=ANA&limit=3", []string{
+			"Go basics", "go advanced", "[brackets]", "apple",
+		}},
+		{"author=éva", nil},
+		{"author=Éva", []string{"Zebra"}},
+	}
+	for kind, store := range testStores(t) {
+		seedCourses(t, store)
+		for _, tt := range tests {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := walk(t, store, values); !slices.Equal(got, tt.want) {
+				t.Errorf("%s ?%s\n got %q\nwant %q", kind, tt.query, got, tt.want)
+			}
+		}
+	}
+}
+
+// TestStoresRefuseNamesThatDifferInASCIICaseOnly, seedCourses already has
+// Éclair and éclair, the non-ASCII letter keeps them apart
+func TestStoresRefuseNamesThatDifferInASCIICaseOnly(t *testing.T) {
+	for kind, store := range testStores(t) {
+		seedCourses(t, store)
+		for _, name := range []string{"GO BASICS", "ÉCLAIR BAKING"} {
+			if _, err := store.Create(Course{CourseName: name}); !errors.Is(err, ErrDuplicateCourse) {
+				t.Errorf("%s: create %s error = %v, want ErrDuplicateCourse", kind, name, err)
+			}
+		}
+	}
+}
+
+// TestFileStoreKeepsMemoryAndDiskTogether, a failed save must not leave
+// the change in memory, a retry would otherwise get 409 for a course that was never saved
+func TestFileStoreKeepsMemoryAndDiskTogether(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewFileStore(filepath.Join(dir, "courses.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := store.Create(Course{CourseName: "Go basics", CoursePrice: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without the directory every save fails
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create(Course{CourseName: "Rust basics"}); err == nil {
+		t.Fatal("create succeeded without a directory to save to")
+	}
+	changed := kept
+	changed.CoursePrice = 1
+	if _, err := store.Update(changed); err == nil {
+		t.Fatal("update succeeded without a directory to save to")
+	}
+	if err := store.Delete(kept.CourseId); err == nil {
+		t.Fatal("delete succeeded without a directory to save to")
+	}
+
+	list, _ := store.List()
+	if len(list) != 1 || list[0] != kept {
+		t.Errorf("after failed saves the store has %+v, want only %+v", list, kept)
+	}
+
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create(Course{CourseName: "Rust basics"}); err != nil {
+		t.Errorf("retry after the failed save: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/SangamSilwal/httpkit/middleware"
+	"github.com/SangamSilwal/httpkit/problem"
+)
+
+const (
+	maxCourseNameLength = 200
+	maxFullnameLength   = 100
+	maxWebsiteLength    = 2048
+)
+
+type FieldError = problem.FieldError
+
+// ValidationError collects every FieldError of a payload, it is answered with 422
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(parts, ", ")
+}
+
+func (e *ValidationError) add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// has reports whether field or an object containing it already failed
+func (e *ValidationError) has(field string) bool {
+	return slices.ContainsFunc(e.Fields, func(f FieldError) bool {
+		return f.Field == field || strings.HasPrefix(field, f.Field+".")
+	})
+}
+
+// orNil returns nil when nothing failed so callers can do `if err != nil`
+func (e *ValidationError) orNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate checks a full course, used by create, update and after a patch is applied
+func (c *Course) Validate() error {
+	errs := &ValidationError{}
+	if c.IsEmpty() {
+		errs.add("coursename", "is required")
+	} else if utf8.RuneCountInString(c.CourseName) > maxCourseNameLength {
+		errs.add("coursename", fmt.Sprintf("must be at most %d characters", maxCourseNameLength))
+	}
+	if c.CoursePrice < 0 {
+		errs.add("price", "must be greater than or equal to 0")
+	}
+	if c.Author != nil {
+		c.Author.validate("author", errs)
+	}
+	return errs.orNil()
+}
+
+func (a *Author) validate(prefix string, errs *ValidationError) {
+	if strings.TrimSpace(a.Fullname) == "" {
+		errs.add(prefix+".fullname", "is required")
+	} else if utf8.RuneCountInString(a.Fullname) > maxFullnameLength {
+		errs.add(prefix+".fullname", fmt.Sprintf("must be at most %d characters", maxFullnameLength))
+	}
+	if a.Website == "" {
+		return
+	}
+	if len(a.Website) > maxWebsiteLength {
+		errs.add(prefix+".website", fmt.Sprintf("must be at most %d characters", maxWebsiteLength))
+		return
+	}
+	u, err := url.ParseRequestURI(a.Website)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add(prefix+".website", "must be a valid http or https URL")
+	}
+}
+
+// ErrBadJSON means the body is not valid JSON at all, it is answered with 400
+var ErrBadJSON = errors.New("request body is not valid JSON")
+
+// decodeStrict decodes exactly one JSON value into v and rejects unknown fields,
+// every unknown field and wrong type comes back in one *ValidationError,
+// v still gets the fields that did decode so Validate can check those too
+func decodeStrict(r *http.Request, v any) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		// the body limit was hit, writeDecodeError answers 413
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fmt.Errorf("%w: body is empty", ErrBadJSON)
+	}
+
+	var raw any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadJSON, err)
+	}
+	if dec.More() {
+		return ErrBadJSON
+	}
+
+	errs := &ValidationError{}
+	checkJSON("", raw, reflect.TypeOf(v), errs)
+	// wrongly typed fields are skipped and the rest is filled in
+	var typeErr *json.UnmarshalTypeError
+	if err := json.Unmarshal(data, v); errors.As(err, &typeErr) && !errs.has(typeErr.Field) {
+		errs.add(typeErr.Field, "must be a "+jsonTypeName(typeErr.Type.Kind().String()))
+	}
+	return errs.orNil()
+}
+
+// checkJSON compares a decoded JSON value with the Go type it is meant for
+// and reports unknown fields and wrong types at their json path
+func checkJSON(path string, value any, t reflect.Type, errs *ValidationError) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil {
+		// null leaves the field as it is
+		return
+	}
+
+	ok := true
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, isObj := value.(map[string]any)
+		if !isObj {
+			ok = false
+			break
+		}
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			field, known := jsonField(t, key)
+			if !known {
+				errs.add(joinPath(path, key), "is not a known field")
+				continue
+			}
+			checkJSON(joinPath(path, jsonName(field)), obj[key], field.Type, errs)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, isNum := value.(json.Number)
+		_, err := n.Int64()
+		ok = isNum && err == nil
+	case reflect.String:
+		_, ok = value.(string)
+	case reflect.Bool:
+		_, ok = value.(bool)
+	}
+	if !ok {
+		errs.add(path, "must be a "+jsonTypeName(t.Kind().String()))
+	}
+}
+
+// jsonField finds the struct field for a json key, ignoring case like encoding/json
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() && jsonName(f) != "-" && strings.EqualFold(jsonName(f), key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "struct", kind == "ptr", kind == "map":
+		return "object"
+	case kind == "slice":
+		return "array"
+	case kind == "bool":
+		return "boolean"
+	default:
+		return kind
+	}
+}
+
+// validated merges the field errors of decodeStrict with those of validate,
+// so one 422 lists every failing field, a field already reported is not repeated
+func validated(decodeErr error, validate func() error) error {
+	errs := &ValidationError{}
+	if decodeErr != nil && !errors.As(decodeErr, &errs) {
+		return decodeErr
+	}
+	var vErr *ValidationError
+	if errors.As(validate(), &vErr) {
+		for _, f := range vErr.Fields {
+			if !errs.has(f.Field) {
+				errs.add(f.Field, f.Message)
+			}
+		}
+	}
+	return errs.orNil()
+}
+
+// writeDecodeError answers a failed decodeStrict or Validate
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if limit, ok := middleware.TooLarge(err); ok {
+		middleware.WriteTooLarge(w, r, limit)
+		return
+	}
+	var vErr *ValidationError
+	if errors.As(err, &vErr) {
+		problem.Write(w, r, problem.New(http.StatusUnprocessableEntity, "One or more fields are invalid").
+			WithErrors(vErr.Fields...))
+		return
+	}
+	problem.Error(w, r, http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SangamSilwal/httpkit/problem"
+)
+
+// failingFields posts body and returns the fields of the 422 answer
+func failingFields(t *testing.T, method, path, body string) []string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("%s %s %s = %d %s, want 422", method, path, body, rec.Code, rec.Body)
+	}
+	var p problem.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, f := range p.Errors {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
+
+func TestOne422ListsEveryFailingField(t *testing.T) {
+	courses = NewMemoryStore()
+	course, err := courses.Create(Course{CourseName: "Go basics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path, body string
+		want               []string
+	}{
+		{http.MethodPost, "/courses", `{"coursename": "Go", "color": "red", "price": -1}`,
+			[]string{"color", "price"}},
+		{http.MethodPost, "/courses", `{"coursename": 5, "price": "free", "author": {"fullname": "", "age": 3, "website": "ftp://x"}}`,
+			[]string{"author.age", "coursename", "price", "author.fullname", "author.website"}},
+		{http.MethodPost, "/courses", `{"coursename": "Go", "price": 1.5, "extra": null}`,
+			[]string{"extra", "price"}},
+		{http.MethodPut, "/courses/" + course.CourseId, `{"unknown": 1, "price": -5}`,
+			[]string{"unknown", "coursename", "price"}},
+		{http.MethodPatch, "/courses/" + course.CourseId, `{"price": "ten", "coursename": "", "author": []}`,
+			[]string{"author", "price", "coursename"}},
+	}
+	for _, tt := range tests {
+		got := failingFields(t, tt.method, tt.path, tt.body)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s %s\n got %q\nwant %q", tt.method, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestBrokenJSONIs400(t *testing.T) {
+	courses = NewMemoryStore()
+	for _, body := range []string{"", "{", `{"coursename": "Go"} {}`, "[1"} {
+		rec := httptest.NewRecorder()
+		newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/courses", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("body %q = %d, want 400", body, rec.Code)
+		}
+	}
+}
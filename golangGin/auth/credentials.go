@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"myGoApp/repository"
+)
+
+// dummyHash is compared against when the email is unknown, so a wrong email
+// takes as long as a wrong password and does not reveal which accounts exist
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("not-a-real-password-1A")
+	return hash
+})
+
+// VerifyCredentials checks email and password against the repository
+// and returns the matching user as a token Subject
+func VerifyCredentials(users repository.UserRepository, email, password string) (Subject, error) {
+	user, err := users.GetByEmail(email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		CheckPassword(dummyHash(), password)
+		return Subject{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return Subject{}, err
+	}
+	if user.PasswordHash == "" || !CheckPassword(user.PasswordHash, password) {
+		return Subject{}, ErrInvalidCredentials
+	}
+	return Subject{ID: strconv.Itoa(user.ID), Email: user.Email, Role: user.Role}, nil
+}
+
+// RepositoryAuthenticator lets LoginHandler check users stored in a UserRepository
+type RepositoryAuthenticator struct {
+	Users repository.UserRepository
+}
+
+func (a RepositoryAuthenticator) Authenticate(email, password string) (Subject, error) {
+	return VerifyCredentials(a.Users, email, password)
+}
+
+// LookupSubject loads the user with the numeric id from the repository
+func (a RepositoryAuthenticator) LookupSubject(id string) (Subject, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return Subject{}, ErrUnknownSubject
+	}
+	user, err := a.Users.Get(n)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return Subject{}, ErrUnknownSubject
+	}
+	if err != nil {
+		return Subject{}, err
+	}
+	return Subject{ID: id, Email: user.Email, Role: user.Role}, nil
+}
+
+// Chain tries each Authenticator in turn until one accepts the credentials
+type Chain []Authenticator
+
+func (c Chain) Authenticate(email, password string) (Subject, error) {
+	for _, a := range c {
+		sub, err := a.Authenticate(email, password)
+		if !errors.Is(err, ErrInvalidCredentials) {
+			return sub, err
+		}
+	}
+	return Subject{}, ErrInvalidCredentials
+}
+
+// LookupSubject asks every member that can look subjects up
+func (c Chain) LookupSubject(id string) (Subject, error) {
+	for _, a := range c {
+		lookup, ok := a.(SubjectLookup)
+		if !ok {
+			continue
+		}
+		sub, err := lookup.LookupSubject(id)
+		if !errors.Is(err, ErrUnknownSubject) {
+			return sub, err
+		}
+	}
+	return Subject{}, ErrUnknownSubject
+}
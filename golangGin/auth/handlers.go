@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"errors"
+	"myGoApp/validation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LoginHandler answers POST /auth/login with a TokenPair
+func LoginHandler(issuer *Issuer, users Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req loginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			validation.AbortWithBindError(c, err)
+			return
+		}
+
+		sub, err := users.Authenticate(req.Email, req.Password)
+		if errors.Is(err, ErrInvalidCredentials) {
+			abort(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			abort(c, http.StatusInternalServerError, "could not check credentials")
+			return
+		}
+		pair, err := issuer.Issue(sub)
+		writeTokens(c, pair, err)
+	}
+}
+
+// RefreshHandler answers POST /auth/refresh, trading a refresh token for a new pair
+// with the subject's current role, 401 when the subject is gone or the token was used before
+func RefreshHandler(issuer *Issuer, subjects SubjectLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req refreshRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			validation.AbortWithBindError(c, err)
+			return
+		}
+		pair, err := issuer.Refresh(req.RefreshToken, subjects)
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrWrongTokenType) ||
+			errors.Is(err, ErrTokenReused) || errors.Is(err, ErrUnknownSubject) {
+			abort(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		writeTokens(c, pair, err)
+	}
+}
+
+func writeTokens(c *gin.Context, pair TokenPair, err error) {
+	if err != nil {
+		c.Error(err)
+		abort(c, http.StatusInternalServerError, "could not issue token")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, pair)
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/SangamSilwal/httpkit/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// keys used in gin.Context, user_id and user_role follow the tutorial
+const (
+	ClaimsKey   = "claims"
+	UserIDKey   = "user_id"
+	UserRoleKey = "user_role"
+)
+
+func abort(c *gin.Context, status int, detail string) {
+	c.Abort()
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	}
+	problem.Write(c.Writer, c.Request, problem.New(status, detail))
+}
+
+// RequireAuth verifies the Bearer access token and stores its claims in the context
+func RequireAuth(issuer *Issuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			abort(c, http.StatusUnauthorized, "a Bearer token in the Authorization header is required")
+			return
+		}
+
+		claims, err := issuer.Verify(strings.TrimSpace(token), TokenTypeAccess)
+		if err != nil {
+			abort(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+		c.Set(UserIDKey, claims.Subject)
+		c.Set(UserRoleKey, claims.Role)
+		c.Next()
+	}
+}
+
+// RequireRole must run after RequireAuth, it lets only the given roles through
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFrom(c)
+		if !ok {
+			abort(c, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		if !slices.Contains(roles, claims.Role) {
+			abort(c, http.StatusForbidden, "role "+claims.Role+" may not access this resource")
+			return
+		}
+		c.Next()
+	}
+}
+
+// ClaimsFrom returns the claims RequireAuth stored
+func ClaimsFrom(c *gin.Context) (*Claims, bool) {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*Claims)
+	return claims, ok
+}
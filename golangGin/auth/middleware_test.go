@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testRouter(issuer *Issuer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/me", RequireAuth(issuer), func(c *gin.Context) { c.String(http.StatusOK, c.GetString(UserIDKey)) })
+	router.GET("/admin", RequireAuth(issuer), RequireRole("admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/refresh", RefreshHandler(issuer, subjects{"1": sam}))
+	return router
+}
+
+func serve(router *gin.Engine, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireAuth(t *testing.T) {
+	issuer := testIssuer(t, "test")
+	router := testRouter(issuer)
+	pair, _ := issuer.Issue(sam)
+
+	for name, token := range map[string]string{
+		"no token":      "",
+		"garbage":       "abc",
+		"refresh token": pair.RefreshToken,
+	} {
+		rec := serve(router, http.MethodGet, "/me", token, "")
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", name, rec.Code)
+		}
+		if rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: no WWW-Authenticate header", name)
+		}
+	}
+
+	rec := serve(router, http.MethodGet, "/me", pair.AccessToken, "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "1" {
+		t.Errorf("access token: %d %q, want 200 1", rec.Code, rec.Body)
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	issuer := testIssuer(t, "test")
+	router := testRouter(issuer)
+
+	user, _ := issuer.Issue(sam)
+	if rec := serve(router, http.MethodGet, "/admin", user.AccessToken, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("user: status = %d, want 403", rec.Code)
+	}
+	admin, _ := issuer.Issue(Subject{ID: "2", Role: "admin"})
+	if rec := serve(router, http.MethodGet, "/admin", admin.AccessToken, ""); rec.Code != http.StatusOK {
+		t.Errorf("admin: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestRefreshHandler(t *testing.T) {
+	issuer := testIssuer(t, "test")
+	router := testRouter(issuer)
+
+	pair, _ := issuer.Issue(sam)
+	body := `{"refresh_token": "` + pair.RefreshToken + `"}`
+	if rec := serve(router, http.MethodPost, "/refresh", "", body); rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status = %d, want 200", rec.Code)
+	}
+	if rec := serve(router, http.MethodPost, "/refresh", "", body); rec.Code != http.StatusUnauthorized {
+		t.Errorf("reused refresh token: status = %d, want 401", rec.Code)
+	}
+
+	gone, _ := issuer.Issue(Subject{ID: "9", Role: "user"})
+	if rec := serve(router, http.MethodPost, "/refresh", "", `{"refresh_token": "`+gone.RefreshToken+`"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("deleted user: status = %d, want 401", rec.Code)
+	}
+}
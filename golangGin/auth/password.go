@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt silently ignores everything after 72 bytes, so longer passwords are refused
+const (
+	MinPasswordLength = 8
+	MaxPasswordBytes  = 72
+)
+
+var ErrWeakPassword = errors.New("password is too weak")
+
+// PasswordError lists why a password was refused
+type PasswordError struct {
+	Reasons []string
+}
+
+func (e *PasswordError) Error() string {
+	return ErrWeakPassword.Error() + ": " + strings.Join(e.Reasons, ", ")
+}
+
+func (e *PasswordError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// ValidatePassword wants 8 to 72 bytes with upper and lower case letters and a digit,
+// and refuses passwords that contain the email's local part
+func ValidatePassword(password, email string) error {
+	var reasons []string
+	if len(password) < MinPasswordLength {
+		reasons = append(reasons, "must be at least 8 characters")
+	}
+	if len(password) > MaxPasswordBytes {
+		reasons = append(reasons, "must be at most 72 bytes")
+	}
+
+	var upper, lower, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !upper || !lower {
+		reasons = append(reasons, "must mix upper and lower case letters")
+	}
+	if !digit {
+		reasons = append(reasons, "must contain a digit")
+	}
+
+	local, _, _ := strings.Cut(email, "@")
+	if len(local) >= 3 && strings.Contains(strings.ToLower(password), strings.ToLower(local)) {
+		reasons = append(reasons, "must not contain the email address")
+	}
+
+	if len(reasons) > 0 {
+		return &PasswordError{Reasons: reasons}
+	}
+	return nil
+}
+
+// HashPassword returns a bcrypt hash with the default cost
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches hash
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,207 @@
+// Package auth issues and verifies the JWTs used by the gin service
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var (
+	ErrInvalidToken       = errors.New("token is invalid or expired")
+	ErrWrongTokenType     = errors.New("wrong token type")
+	ErrInvalidCredentials = errors.New("email or password is wrong")
+	ErrUnknownSubject     = errors.New("the user of this token no longer exists")
+	ErrTokenReused        = errors.New("refresh token was already used")
+)
+
+// Subject is who a token is issued to
+type Subject struct {
+	ID    string
+	Email string
+	Role  string
+}
+
+// Authenticator checks a login, the login handler does not care where users live
+type Authenticator interface {
+	Authenticate(email, password string) (Subject, error)
+}
+
+// SubjectLookup loads a Subject as it is now, refresh uses it so a
+// changed role or a deleted user takes effect without waiting for the refresh TTL
+type SubjectLookup interface {
+	LookupSubject(id string) (Subject, error)
+}
+
+// Claims are the JWT claims, Type tells an access token from a refresh token
+type Claims struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+	Type  string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// TokenPair is what login and refresh return
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+// Config holds the HMAC key and token lifetimes
+type Config struct {
+	Key        []byte
+	Issuer     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// Issuer signs and verifies tokens with HMAC-SHA256
+type Issuer struct {
+	cfg Config
+	now func() time.Time
+
+	mu sync.Mutex
+	// used holds the jti of every redeemed refresh token until it expires,
+	// it is kept in memory, so a restart with the same key forgets it
+	used map[string]time.Time
+}
+
+// NewIssuer refuses keys shorter than 32 bytes, HS256 needs at least that much
+func NewIssuer(cfg Config) (*Issuer, error) {
+	if len(cfg.Key) < 32 {
+		return nil, fmt.Errorf("jwt key must be at least 32 bytes, got %d", len(cfg.Key))
+	}
+	if cfg.AccessTTL <= 0 {
+		cfg.AccessTTL = 15 * time.Minute
+	}
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = 7 * 24 * time.Hour
+	}
+	return &Issuer{cfg: cfg, now: time.Now, used: make(map[string]time.Time)}, nil
+}
+
+// RandomKey is used when no key is configured, tokens then die with the process
+func RandomKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// Issue signs a new access and refresh token for sub
+func (i *Issuer) Issue(sub Subject) (TokenPair, error) {
+	access, err := i.sign(sub, TokenTypeAccess, i.cfg.AccessTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	refresh, err := i.sign(sub, TokenTypeRefresh, i.cfg.RefreshTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(i.cfg.AccessTTL.Seconds()),
+		RefreshExpiresIn: int(i.cfg.RefreshTTL.Seconds()),
+	}, nil
+}
+
+func (i *Issuer) sign(sub Subject, typ string, ttl time.Duration) (string, error) {
+	now := i.now()
+	claims := Claims{
+		Email: sub.Email,
+		Role:  sub.Role,
+		Type:  typ,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        newTokenID(),
+			Subject:   sub.ID,
+			Issuer:    i.cfg.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.cfg.Key)
+}
+
+func newTokenID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Verify checks signature, expiry, issuer and that the token is of type typ
+func (i *Issuer) Verify(token, typ string) (*Claims, error) {
+	claims := &Claims{}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(i.now),
+	}
+	if i.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(i.cfg.Issuer))
+	}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return i.cfg.Key, nil
+	}, opts...)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Type != typ {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
+}
+
+// Refresh trades a valid refresh token for a new pair, the subject is
+// reloaded through subjects instead of trusting the role in the old token,
+// every refresh token works once, the new pair carries the next one
+func (i *Issuer) Refresh(refreshToken string, subjects SubjectLookup) (TokenPair, error) {
+	claims, err := i.Verify(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if err := i.redeem(claims); err != nil {
+		return TokenPair{}, err
+	}
+	sub, err := subjects.LookupSubject(claims.Subject)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return i.Issue(sub)
+}
+
+// redeem marks the refresh token used, expired entries are dropped on the way
+// since Verify refuses those tokens anyway
+func (i *Issuer) redeem(claims *Claims) error {
+	if claims.ID == "" {
+		return ErrInvalidToken
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := i.now()
+	for id, expires := range i.used {
+		if now.After(expires) {
+			delete(i.used, id)
+		}
+	}
+	if _, ok := i.used[claims.ID]; ok {
+		return ErrTokenReused
+	}
+	i.used[claims.ID] = claims.ExpiresAt.Time
+	return nil
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func testIssuer(t *testing.T, name string) *Issuer {
+	t.Helper()
+	issuer, err := NewIssuer(Config{Key: RandomKey(), Issuer: name, AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issuer
+}
+
+// subjects is a SubjectLookup over a map, deleting an entry deletes the user
+type subjects map[string]Subject
+
+func (s subjects) LookupSubject(id string) (Subject, error) {
+	sub, ok := s[id]
+	if !ok {
+		return Subject{}, ErrUnknownSubject
+	}
+	return sub, nil
+}
+
+var sam = Subject{ID: "1", Email: "sam@example.com", Role: "user"}
+
+func TestVerify(t *testing.T) {
+	issuer := testIssuer(t, "test")
+	pair, err := issuer.Issue(sam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := issuer.Verify(pair.AccessToken, TokenTypeAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "1" || claims.Role != "user" || claims.Email != sam.Email {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	issuer := testIssuer(t, "test")
+	pair, _ := issuer.Issue(sam)
+
+	otherKey := testIssuer(t, "test")
+	sameKeyOtherName, err := NewIssuer(Config{Key: issuer.cfg.Key, Issuer: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := testIssuer(t, "test")
+	later.cfg.Key = issuer.cfg.Key
+	later.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	tests := []struct {
+		name   string
+		issuer *Issuer
+		token  string
+		typ    string
+		want   error
+	}{
+		{"wrong signature", otherKey, pair.AccessToken, TokenTypeAccess, ErrInvalidToken},
+		{"wrong issuer", sameKeyOtherName, pair.AccessToken, TokenTypeAccess, ErrInvalidToken},
+		{"expired", later, pair.AccessToken, TokenTypeAccess, ErrInvalidToken},
+		{"refresh as access", issuer, pair.RefreshToken, TokenTypeAccess, ErrWrongTokenType},
+		{"access as refresh", issuer, pair.AccessToken, TokenTypeRefresh, ErrWrongTokenType},
+		{"not a jwt", issuer, "abc.def.ghi", TokenTypeAccess, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		if _, err := tt.issuer.Verify(tt.token, tt.typ); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRefreshUsesTheCurrentSubject(t *testing.T) {
+	issuer := testIssuer(t, "test")
+	users := subjects{"1": sam}
+	pair, _ := issuer.Issue(sam)
+
+	users["1"] = Subject{ID: "1", Email: sam.Email, Role: "admin"}
+	pair, err := issuer.Refresh(pair.RefreshToken, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := issuer.Verify(pair.AccessToken, TokenTypeAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Role != "admin" {
+		t.Errorf("role after refresh = %q, want admin", claims.Role)
+	}
+
+	delete(users, "1")
+	if _, err := issuer.Refresh(pair.RefreshToken, users); !errors.Is(err, ErrUnknownSubject) {
+		t.Errorf("refresh of a deleted user error = %v, want ErrUnknownSubject", err)
+	}
+}
+
+func TestRefreshTokenWorksOnce(t *testing.T) {
+	issuer := testIssuer(t, "test")
+	users := subjects{"1": sam}
+	first, _ := issuer.Issue(sam)
+
+	second, err := issuer.Refresh(first.RefreshToken, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := issuer.Refresh(first.RefreshToken, users); !errors.Is(err, ErrTokenReused) {
+		t.Errorf("second refresh with the same token error = %v, want ErrTokenReused", err)
+	}
+	if _, err := issuer.Refresh(second.RefreshToken, users); err != nil {
+		t.Errorf("refresh with the rotated token error = %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"myGoApp/validation"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,8 +14,9 @@ type FormA struct {
 func SomeHandler(c *gin.Context) {
 	objA := FormA{}
 
-	if errA := c.ShouldBind(&objA); errA == nil {
-		c.String(http.StatusOK, `The body should be formA`)
+	if errA := c.ShouldBind(&objA); errA != nil {
+		validation.AbortWithBindError(c, errA)
+		return
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Received",
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"myGoApp/auth"
+	"myGoApp/repository"
+	"myGoApp/storage"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+// AvatarTypes are the image types accepted, checked on the bytes, not the file name
+var AvatarTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// AvatarController serves POST and GET /users/:id/avatar
+type AvatarController struct {
+	Users *UserController
+	Store storage.Store
+	// MaxBytes is the largest avatar accepted
+	MaxBytes int64
+}
+
+// Upload takes a multipart form with the image in the avatar field,
+// users may only change their own avatar, admins any
+func (ac *AvatarController) Upload(c *gin.Context) {
+	user, ok := ac.Users.load(c)
+	if !ok {
+		return
+	}
+	claims, _ := auth.ClaimsFrom(c)
+	if claims == nil || (claims.Role != "admin" && claims.Subject != strconv.Itoa(user.ID)) {
+		AbortWithError(c, http.StatusForbidden, "you may only change your own avatar")
+		return
+	}
+
+	// a little room on top of MaxBytes for the multipart boundaries and headers
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ac.MaxBytes+64<<10)
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			AbortWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("avatar must be at most %d bytes", ac.MaxBytes))
+			return
+		}
+		AbortWithError(c, http.StatusBadRequest, "send the image as multipart/form-data in the avatar field")
+		return
+	}
+	if header.Size > ac.MaxBytes {
+		AbortWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("avatar must be at most %d bytes", ac.MaxBytes))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		abortInternal(c, err, "could not read upload")
+		return
+	}
+	defer file.Close()
+
+	// sniff the real type from the first bytes, the client's Content-Type is not trusted
+	mtype, err := mimetype.DetectReader(file)
+	if err != nil {
+		abortInternal(c, err, "could not read upload")
+		return
+	}
+	if !slices.ContainsFunc(AvatarTypes, mtype.Is) {
+		AbortWithError(c, http.StatusUnsupportedMediaType, "avatar must be a PNG, JPEG, GIF or WebP image, got "+mtype.String())
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		abortInternal(c, err, "could not read upload")
+		return
+	}
+
+	name := storage.NewName(strconv.Itoa(user.ID), mtype.Extension())
+	if err := ac.Store.Put(name, file); err != nil {
+		abortInternal(c, err, "could not store avatar")
+		return
+	}
+
+	// only the avatar changes, and only if no other upload finished since load
+	if err := ac.Users.Repo.SetAvatar(user.ID, user.Avatar, name); err != nil {
+		ac.Store.Delete(name)
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			AbortWithError(c, http.StatusNotFound, "No user found with the given id")
+		case errors.Is(err, repository.ErrAvatarChanged):
+			AbortWithError(c, http.StatusConflict, "the avatar was changed by another upload, try again")
+		default:
+			abortInternal(c, err, "could not save user")
+		}
+		return
+	}
+	if user.Avatar != "" {
+		ac.Store.Delete(user.Avatar)
+	}
+
+	location := c.Request.URL.Path
+	c.Header("Location", location)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Avatar uploaded Successfully",
+		"avatar":       location,
+		"content_type": mtype.String(),
+		"size":         header.Size,
+	})
+}
+
+// Get serves the avatar, the ETag changes with every upload so clients
+// may cache it and revalidate cheaply with If-None-Match
+func (ac *AvatarController) Get(c *gin.Context) {
+	user, ok := ac.Users.load(c)
+	if !ok {
+		return
+	}
+	if user.Avatar == "" {
+		AbortWithError(c, http.StatusNotFound, "this user has no avatar")
+		return
+	}
+
+	file, modTime, err := ac.Store.Open(user.Avatar)
+	if errors.Is(err, storage.ErrNotFound) {
+		AbortWithError(c, http.StatusNotFound, "this user has no avatar")
+		return
+	}
+	if err != nil {
+		abortInternal(c, err, "could not open avatar")
+		return
+	}
+	defer file.Close()
+
+	c.Header("Cache-Control", "public, max-age=3600, must-revalidate")
+	c.Header("ETag", `"`+user.Avatar+`"`)
+	c.Header("X-Content-Type-Options", "nosniff")
+	// ServeContent picks Content-Type from the extension and answers
+	// If-None-Match, If-Modified-Since and Range requests
+	http.ServeContent(c.Writer, c.Request, user.Avatar, modTime, file)
+}
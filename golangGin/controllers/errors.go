@@ -0,0 +1,35 @@
+// Package controllers holds one controller type per resource,
+// the route table in main wires their methods to versioned paths
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/SangamSilwal/httpkit/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// AbortWithProblem stops the handler chain and answers with application/problem+json
+func AbortWithProblem(c *gin.Context, p *problem.Problem) {
+	c.Abort()
+	problem.Write(c.Writer, c.Request, p)
+}
+
+// AbortWithError is the short form for a problem without field errors
+func AbortWithError(c *gin.Context, status int, detail string) {
+	AbortWithProblem(c, problem.New(status, detail))
+}
+
+// abortInternal records err for the logger and hides it from the client
+func abortInternal(c *gin.Context, err error, detail string) {
+	c.Error(err)
+	AbortWithError(c, http.StatusInternalServerError, detail)
+}
+
+func NoRoute(c *gin.Context) {
+	AbortWithError(c, http.StatusNotFound, "no route matches "+c.Request.URL.Path)
+}
+
+func NoMethod(c *gin.Context) {
+	AbortWithError(c, http.StatusMethodNotAllowed, c.Request.Method+" is not allowed on "+c.Request.URL.Path)
+}
@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HomeController serves the routes that are not about a resource
+type HomeController struct{}
+
+func (HomeController) Index(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "hello from gin framework",
+		"Status":  "success",
+	})
+}
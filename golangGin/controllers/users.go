@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"myGoApp/auth"
+	"myGoApp/filter"
+	"myGoApp/repository"
+	NewUserType "myGoApp/types"
+	"myGoApp/validation"
+
+	"github.com/SangamSilwal/httpkit/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// UserController serves /api/v1/users, the v1 response shape
+type UserController struct {
+	Repo repository.UserRepository
+}
+
+func (uc *UserController) List(c *gin.Context) {
+	list, ok := uc.list(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"Data":    list,
+		"message": "User retrieved Succesfully",
+	})
+}
+
+func (uc *UserController) Create(c *gin.Context) {
+	user, ok := uc.create(c)
+	if !ok {
+		return
+	}
+	c.Header("Location", "/api/v1/users/"+strconv.Itoa(user.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "User Created Successfully",
+		"User":    user,
+	})
+}
+
+func (uc *UserController) Get(c *gin.Context) {
+	user, ok := uc.load(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User retrieved Succesfully",
+		"User":    user,
+	})
+}
+
+// Filter answers /filter?tags=go,web&age>=18&q=sam&sort=-age, see package filter
+func (uc *UserController) Filter(c *gin.Context) {
+	f, err := filter.Parse(c.Request.URL.RawQuery)
+	if err != nil {
+		AbortWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	list, err := uc.Repo.Find(f)
+	if err != nil {
+		abortInternal(c, err, "could not filter users")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"Data":    list,
+		"filter":  f,
+		"message": "Filter applied",
+	})
+}
+
+// The helpers below do the work for every version, they answer the
+// error themselves and return ok=false so the caller only renders success
+
+func (uc *UserController) list(c *gin.Context) ([]NewUserType.User, bool) {
+	list, err := uc.Repo.List()
+	if err != nil {
+		abortInternal(c, err, "could not load users")
+		return nil, false
+	}
+	return list, true
+}
+
+// create binds a CreateUserRequest, checks the password, hashes it and stores the user
+func (uc *UserController) create(c *gin.Context) (NewUserType.User, bool) {
+	var req NewUserType.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.AbortWithBindError(c, err)
+		return NewUserType.User{}, false
+	}
+	var weak *auth.PasswordError
+	if err := auth.ValidatePassword(req.Password, req.Email); errors.As(err, &weak) {
+		p := problem.New(http.StatusUnprocessableEntity, "password is too weak")
+		for _, reason := range weak.Reasons {
+			p.WithErrors(problem.FieldError{Field: "password", Message: reason})
+		}
+		AbortWithProblem(c, p)
+		return NewUserType.User{}, false
+	}
+
+	user := req.User
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		abortInternal(c, err, "could not hash password")
+		return NewUserType.User{}, false
+	}
+	user.PasswordHash = hash
+
+	user, err = uc.Repo.Create(user)
+	if errors.Is(err, repository.ErrEmailTaken) {
+		AbortWithError(c, http.StatusConflict, err.Error())
+		return NewUserType.User{}, false
+	}
+	if err != nil {
+		abortInternal(c, err, "could not save user")
+		return NewUserType.User{}, false
+	}
+	return user, true
+}
+
+// load reads the :id param and fetches that user
+func (uc *UserController) load(c *gin.Context) (NewUserType.User, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		AbortWithError(c, http.StatusBadRequest, "Invalid user ID format")
+		return NewUserType.User{}, false
+	}
+
+	user, err := uc.Repo.Get(id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		AbortWithError(c, http.StatusNotFound, "No user found with the given id")
+		return NewUserType.User{}, false
+	}
+	if err != nil {
+		abortInternal(c, err, "could not load user")
+		return NewUserType.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	NewUserType "myGoApp/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserControllerV2 serves /api/v2/users, same rules as v1 but every
+// response is {"data": ...} with lower case keys and a self link
+type UserControllerV2 struct {
+	*UserController
+}
+
+type userLinks struct {
+	Self string `json:"self"`
+}
+
+// userV2 is the v2 representation of a user
+type userV2 struct {
+	NewUserType.User
+	Links userLinks `json:"links"`
+}
+
+type listMeta struct {
+	Count int `json:"count"`
+}
+
+func toUserV2(user NewUserType.User) userV2 {
+	return userV2{User: user, Links: userLinks{Self: "/api/v2/users/" + strconv.Itoa(user.ID)}}
+}
+
+func (uc *UserControllerV2) List(c *gin.Context) {
+	list, ok := uc.list(c)
+	if !ok {
+		return
+	}
+	data := make([]userV2, 0, len(list))
+	for _, user := range list {
+		data = append(data, toUserV2(user))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "meta": listMeta{Count: len(data)}})
+}
+
+func (uc *UserControllerV2) Create(c *gin.Context) {
+	user, ok := uc.create(c)
+	if !ok {
+		return
+	}
+	v2 := toUserV2(user)
+	c.Header("Location", v2.Links.Self)
+	c.JSON(http.StatusCreated, gin.H{"data": v2})
+}
+
+func (uc *UserControllerV2) Get(c *gin.Context) {
+	user, ok := uc.load(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": toUserV2(user)})
+}
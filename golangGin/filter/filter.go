@@ -0,0 +1,236 @@
+// Package filter parses the /filter query language into a typed Filter
+// that a repository can execute against users
+//
+//	/filter?tags=go,web&tags=api&category=dev&age>=18&age<30&q=sam&sort=-age,name
+//
+// every part of the query string is one expression: field=value, a range
+// like age>=18, or one of the reserved keys q and sort
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	NewUserType "myGoApp/types"
+)
+
+// ErrMalformed wraps every parse error, handlers answer it with 400
+var ErrMalformed = errors.New("malformed filter")
+
+// Op is a comparison used by a Range
+type Op string
+
+const (
+	OpEq  Op = "="
+	OpNe  Op = "!="
+	OpGt  Op = ">"
+	OpGte Op = ">="
+	OpLt  Op = "<"
+	OpLte Op = "<="
+)
+
+// longest first, so >= is found before >
+var ops = []Op{OpGte, OpLte, OpNe, OpGt, OpLt, OpEq}
+
+// Range compares a numeric field, age>=18
+type Range struct {
+	Field string `json:"field"`
+	Op    Op     `json:"op"`
+	Value int    `json:"value"`
+}
+
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+// Filter is a parsed query, the zero value matches every user
+type Filter struct {
+	// Tags must all be present on the user
+	Tags     []string    `json:"tags,omitempty"`
+	Category string      `json:"category,omitempty"`
+	Role     string      `json:"role,omitempty"`
+	Ranges   []Range     `json:"ranges,omitempty"`
+	Search   string      `json:"search,omitempty"`
+	Sort     []SortField `json:"sort,omitempty"`
+}
+
+var (
+	numericFields  = []string{"id", "age"}
+	sortableFields = []string{"id", "age", "name", "email"}
+)
+
+func malformed(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrMalformed, fmt.Sprintf(format, args...))
+}
+
+// Parse reads a raw query string, it is not parsed with url.ParseQuery
+// because that splits age>=18 into the key "age>" and the value "18"
+func Parse(rawQuery string) (Filter, error) {
+	var f Filter
+	if rawQuery == "" {
+		return f, nil
+	}
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		expr, err := url.QueryUnescape(part)
+		if err != nil {
+			return f, malformed("%q is not valid URL encoding", part)
+		}
+		if err := f.add(expr); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// add applies one expression to f
+func (f *Filter) add(expr string) error {
+	field, op, value, ok := splitExpr(expr)
+	if !ok {
+		return malformed("%q is not an expression like field=value or age>=18", expr)
+	}
+	field = strings.ToLower(strings.TrimSpace(field))
+	value = strings.TrimSpace(value)
+
+	if slices.Contains(numericFields, field) {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return malformed("%s needs a whole number, got %q", field, value)
+		}
+		f.Ranges = append(f.Ranges, Range{Field: field, Op: op, Value: n})
+		return nil
+	}
+
+	if op != OpEq {
+		return malformed("%s only supports =, got %s", field, op)
+	}
+	switch field {
+	case "tags", "tag":
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				f.Tags = append(f.Tags, strings.ToLower(tag))
+			}
+		}
+	case "category":
+		f.Category = value
+	case "role":
+		f.Role = value
+	case "q":
+		f.Search = value
+	case "sort":
+		return f.addSort(value)
+	default:
+		return malformed("unknown field %q", field)
+	}
+	return nil
+}
+
+// addSort reads sort=-age,name, a leading - means descending
+func (f *Filter) addSort(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		s := SortField{Field: strings.ToLower(strings.TrimSpace(part))}
+		if strings.HasPrefix(s.Field, "-") {
+			s.Desc = true
+			s.Field = s.Field[1:]
+		}
+		if !slices.Contains(sortableFields, s.Field) {
+			return malformed("cannot sort by %q", s.Field)
+		}
+		f.Sort = append(f.Sort, s)
+	}
+	return nil
+}
+
+// splitExpr finds the first operator in expr
+func splitExpr(expr string) (field string, op Op, value string, ok bool) {
+	for i := 0; i < len(expr); i++ {
+		for _, o := range ops {
+			if strings.HasPrefix(expr[i:], string(o)) {
+				field, value = expr[:i], expr[i+len(o):]
+				return field, o, value, field != ""
+			}
+		}
+	}
+	return "", "", "", false
+}
+
+// Match reports whether user passes every condition
+func (f Filter) Match(user NewUserType.User) bool {
+	for _, tag := range f.Tags {
+		if !slices.ContainsFunc(user.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return false
+		}
+	}
+	if f.Category != "" && !strings.EqualFold(user.Category, f.Category) {
+		return false
+	}
+	if f.Role != "" && user.Role != f.Role {
+		return false
+	}
+	for _, r := range f.Ranges {
+		if !r.match(numericField(user, r.Field)) {
+			return false
+		}
+	}
+	if f.Search != "" {
+		q := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(user.Name), q) && !strings.Contains(strings.ToLower(user.Email), q) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Range) match(v int) bool {
+	switch r.Op {
+	case OpEq:
+		return v == r.Value
+	case OpNe:
+		return v != r.Value
+	case OpGt:
+		return v > r.Value
+	case OpGte:
+		return v >= r.Value
+	case OpLt:
+		return v < r.Value
+	case OpLte:
+		return v <= r.Value
+	}
+	return false
+}
+
+func numericField(user NewUserType.User, field string) int {
+	if field == "age" {
+		return user.Age
+	}
+	return user.ID
+}
+
+// Compare orders a and b by f.Sort, ties fall back to the id
+func (f Filter) Compare(a, b NewUserType.User) int {
+	for _, s := range f.Sort {
+		var c int
+		switch s.Field {
+		case "id", "age":
+			c = numericField(a, s.Field) - numericField(b, s.Field)
+		case "name":
+			c = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case "email":
+			c = strings.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email))
+		}
+		if c != 0 {
+			if s.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return a.ID - b.ID
+}
@@ -0,0 +1,58 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Filter
+	}{
+		{"", Filter{}},
+		{"tags=go&tags=web", Filter{Tags: []string{"go", "web"}}},
+		{"tags=Go,web&tags=api", Filter{Tags: []string{"go", "web", "api"}}},
+		{"tags=go,,%20web%20", Filter{Tags: []string{"go", "web"}}},
+		{"category=dev&role=admin", Filter{Category: "dev", Role: "admin"}},
+		{"age>=18", Filter{Ranges: []Range{{Field: "age", Op: OpGte, Value: 18}}}},
+		{"age<30", Filter{Ranges: []Range{{Field: "age", Op: OpLt, Value: 30}}}},
+		{"age!=5", Filter{Ranges: []Range{{Field: "age", Op: OpNe, Value: 5}}}},
+		{"age%3E%3D18&id=3", Filter{Ranges: []Range{{Field: "age", Op: OpGte, Value: 18}, {Field: "id", Op: OpEq, Value: 3}}}},
+		{"sort=-age,name", Filter{Sort: []SortField{{Field: "age", Desc: true}, {Field: "name"}}}},
+		{"q=sam+smith", Filter{Search: "sam smith"}},
+		{"q=a%3Db", Filter{Search: "a=b"}},
+		{"q=a=b", Filter{Search: "a=b"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	for _, query := range []string{
+		"color=red",
+		"age>=old",
+		"age<",
+		"id=1.5",
+		"category>=x",
+		"tags!=go",
+		"sort=-height",
+		"sort=age,",
+		">=18",
+		"age",
+		"q=%zz",
+	} {
+		if _, err := Parse(query); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Parse(%q) error = %v, want ErrMalformed", query, err)
+		}
+	}
+}
@@ -1,79 +1,118 @@
 package main
 
 import (
+	"cmp"
+	"flag"
 	"fmt"
-	NewUserType "myGoApp/types"
-	"net/http"
-	"strconv"
+	"log"
+	"myGoApp/auth"
+	"myGoApp/controllers"
+	"myGoApp/repository"
+	"myGoApp/storage"
+	"myGoApp/validation"
+	"os"
+	"time"
 
-	"github.com/gin-gonic/gin"
+	"github.com/SangamSilwal/httpkit/metrics"
+	"github.com/SangamSilwal/httpkit/middleware"
+	"github.com/SangamSilwal/httpkit/ratelimit"
+	"github.com/SangamSilwal/httpkit/server"
 )
 
-func main() {
+// openUserRepository picks the UserRepository implementation at startup
+func openUserRepository(kind, dataFile string) (repository.UserRepository, error) {
+	switch kind {
+	case "memory":
+		return repository.NewMemoryUserRepository(), nil
+	case "file":
+		return repository.NewFileUserRepository(dataFile)
+	default:
+		return nil, fmt.Errorf("unknown user store %q, use memory or file", kind)
+	}
+}
 
-	//This gin.Default set up a router with logger abd recovery middleware attached
-	router := gin.Default()
-	router.GET("/", func(ctx *gin.Context) {
-		ctx.JSON(http.StatusOK, gin.H{
-			"message": "hello from gin framework",
-			"Status":  "success",
-		})
-	})
-	router.GET("/users/data", func(c *gin.Context) {
-		users := []gin.H{
-			{"name": "sangam Silwal", "id": 123},
-			{"name": "Ram lal chaudhary", "id": 123},
-		}
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
 
-		c.JSON(http.StatusOK, gin.H{
-			"Data":    users,
-			"message": "User retrieved Succesfully",
-		})
-	})
+func main() {
+	cfg := server.DefaultConfig("8080")
+	cfg.RegisterFlags(flag.CommandLine)
+	jwtKey := flag.String("jwt-key", "", "HMAC key for signing tokens, at least 32 bytes (env JWT_KEY)")
+	accessTTL := flag.Duration("jwt-access-ttl", 15*time.Minute, "lifetime of access tokens")
+	refreshTTL := flag.Duration("jwt-refresh-ttl", 7*24*time.Hour, "lifetime of refresh tokens")
+	userStore := flag.String("user-store", "memory", "where to keep users: memory or file")
+	userData := flag.String("user-data", "users.json", "data file used by the file user store")
+	rulesFile := flag.String("rules", os.Getenv("VALIDATION_RULES"), "JSON file with roles and disallowed email domains (env VALIDATION_RULES)")
+	avatarDir := flag.String("avatar-dir", envOr("AVATAR_DIR", "uploads/avatars"), "directory avatars are stored in (env AVATAR_DIR)")
+	avatarMax := flag.Int64("avatar-max-bytes", 2<<20, "largest avatar upload accepted")
+	apiLimit := flag.Int("rate-limit", 120, "requests per minute per user, or per client IP when not signed in, 0 disables")
+	ipLimit := flag.Int("ip-rate-limit", 300, "requests per minute per client IP to routes needing a token, counted before the token is checked, 0 disables")
+	loginLimit := flag.Int("login-rate-limit", 10, "login and refresh attempts per minute per client IP, 0 disables")
+	httpCfg := httpConfig{
+		cors:     middleware.DefaultCORSConfig(),
+		security: middleware.DefaultSecurityConfig(),
+	}
+	httpCfg.cors.RegisterFlags(flag.CommandLine)
+	httpCfg.security.RegisterFlags(flag.CommandLine)
+	flag.Int64Var(&httpCfg.maxBodyBytes, "max-body-bytes", middleware.DefaultMaxBodyBytes, "largest JSON body accepted, bigger ones get 413")
+	flag.Parse()
 
-	router.POST("/users", func(c *gin.Context) {
-		var user NewUserType.User
-		if err := c.ShouldBindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   "ValidationError",
-				"Details": err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusCreated, gin.H{
-			"message": "User Created Successfully",
-			"User":    user,
-		})
-	})
+	rules, err := validation.LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := validation.Setup(rules); err != nil {
+		log.Fatal(err)
+	}
+	repo, err := openUserRepository(*userStore, *userData)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	router.GET("/users/:id", func(ctx *gin.Context) {
-		userID := ctx.Param("id")
-		id, err := strconv.Atoi(userID)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid user ID format",
-			})
-			return
-		}
+	// read after Parse, as a flag default the key would show up in -h
+	key := []byte(cmp.Or(*jwtKey, os.Getenv("JWT_KEY")))
+	if len(key) == 0 {
+		log.Println("JWT_KEY is not set, using a random key, tokens will not survive a restart")
+		key = auth.RandomKey()
+	}
+	issuer, err := auth.NewIssuer(auth.Config{Key: key, Issuer: "myGoApp", AccessTTL: *accessTTL, RefreshTTL: *refreshTTL})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// the admin from the environment can always log in, everyone else comes from the repository
+	authenticator := auth.Chain{
+		auth.StaticAuthenticator{
+			Subject:  auth.Subject{ID: "admin", Email: os.Getenv("ADMIN_EMAIL"), Role: "admin"},
+			Password: os.Getenv("ADMIN_PASSWORD"),
+		},
+		auth.RepositoryAuthenticator{Users: repo},
+	}
 
-		ctx.JSON(http.StatusOK, gin.H{
-			"Message": "Param taken Successfully",
-			"ParamId": id,
-		})
-	})
+	avatarStore, err := storage.NewLocalStore(*avatarDir)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	//Multiple parameters in URL
-	router.GET("/filter", func(ctx *gin.Context) {
-		tags := ctx.QueryArray("tags")
-		fmt.Println(tags)
-		categories := ctx.Query("tags")
-		fmt.Println(categories)
-		ctx.JSON(http.StatusOK, gin.H{
-			"tags":       tags,
-			"categories": categories,
-			"message":    "Filter applied",
-		})
+	users := &controllers.UserController{Repo: repo}
+	httpMetrics := metrics.New()
+	router := newRouter(app{
+		issuer:        issuer,
+		authenticator: authenticator,
+		subjects:      authenticator,
+		users:         users,
+		avatars:       &controllers.AvatarController{Users: users, Store: avatarStore, MaxBytes: *avatarMax},
+		apiLimit:      ratelimit.New(ratelimit.Config{Requests: *apiLimit, Per: time.Minute}),
+		ipLimit:       ratelimit.New(ratelimit.Config{Requests: *ipLimit, Per: time.Minute}),
+		loginLimit:    ratelimit.New(ratelimit.Config{Requests: *loginLimit, Per: time.Minute, Burst: 5}),
+		http:          httpCfg,
+		metrics:       httpMetrics,
 	})
 
-	router.Run(":8080")
+	if err := server.Run(cfg, withMiddleware(router, httpCfg, httpMetrics)); err != nil {
+		log.Println(err)
+	}
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"myGoApp/auth"
+
+	"github.com/SangamSilwal/httpkit/middleware"
+	"github.com/SangamSilwal/httpkit/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// TestMetricsCountEveryAnswer, preflights are answered by CORS before gin runs
+// and must be counted like the mux service counts them
+func TestMetricsCountEveryAnswer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := testApp(t)
+	cfg := httpConfig{cors: middleware.DefaultCORSConfig(), security: middleware.DefaultSecurityConfig()}
+	cfg.cors.AllowedOrigins = []string{"https://app.example"}
+	h := withMiddleware(newRouter(a), cfg, a.metrics)
+
+	serve := func(method, path, origin string) {
+		req := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve(http.MethodOptions, "/users/7", "https://app.example")
+	serve(http.MethodOptions, "/users/7", "https://evil.example")
+	serve(http.MethodGet, "/users/7", "")
+	serve(http.MethodGet, "/nowhere", "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`http_requests_total{method="OPTIONS",route="unmatched",status="204"} 1`,
+		`http_requests_total{method="OPTIONS",route="unmatched",status="403"} 1`,
+		`http_requests_total{method="GET",route="/users/:id",status="401"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+// TestRateLimitHeadersShowTheTighterLimit, a route needing a token passes
+// the per IP and the per user limiter, the later one must not hide the earlier
+func TestRateLimitHeadersShowTheTighterLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := testApp(t)
+	a.ipLimit = ratelimit.New(ratelimit.Config{Requests: 2, Per: time.Minute})
+	a.apiLimit = ratelimit.New(ratelimit.Config{Requests: 100, Per: time.Minute})
+	router := newRouter(a)
+	pair, err := a.issuer.Issue(auth.Subject{ID: "1", Role: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/data", nil)
+		req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	for _, want := range []string{"1", "0"} {
+		rec := get()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		if got := rec.Header().Get("RateLimit-Limit") + "/" + rec.Header().Get("RateLimit-Remaining"); got != "2/"+want {
+			t.Errorf("RateLimit-Limit/Remaining = %s, want 2/%s", got, want)
+		}
+	}
+	if rec := get(); rec.Code != http.StatusTooManyRequests || rec.Header().Get("RateLimit-Limit") != "2" {
+		t.Errorf("third request = %d with limit %q, want 429 from the IP limiter", rec.Code, rec.Header().Get("RateLimit-Limit"))
+	}
+}
@@ -0,0 +1,183 @@
+// Package repository stores the users behind the /users endpoints
+package repository
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"myGoApp/filter"
+	NewUserType "myGoApp/types"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("a user with this email already exists")
+	// ErrAvatarChanged means another upload replaced the avatar first
+	ErrAvatarChanged = errors.New("avatar changed meanwhile")
+)
+
+// UserRepository is what the handlers use, emails are unique ignoring case
+type UserRepository interface {
+	// Create assigns a new ID and returns the stored user
+	Create(user NewUserType.User) (NewUserType.User, error)
+	Get(id int) (NewUserType.User, error)
+	// Update replaces the user with the same ID
+	Update(user NewUserType.User) (NewUserType.User, error)
+	// SetAvatar changes only the avatar, and only while it is still old,
+	// so it never overwrites other fields changed since the user was loaded
+	SetAvatar(id int, old, avatar string) error
+	GetByEmail(email string) (NewUserType.User, error)
+	// List returns every user ordered by ID
+	List() ([]NewUserType.User, error)
+	// Find returns the users matching f in the order f asks for
+	Find(f filter.Filter) ([]NewUserType.User, error)
+}
+
+// MemoryUserRepository keeps users in a map, IDs count up from 1
+type MemoryUserRepository struct {
+	mu      sync.RWMutex
+	users   map[int]NewUserType.User
+	byEmail map[string]int
+	order   []int
+	nextID  int
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:   make(map[int]NewUserType.User),
+		byEmail: make(map[string]int),
+		nextID:  1,
+	}
+}
+
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (r *MemoryUserRepository) Create(user NewUserType.User) (NewUserType.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byEmail[emailKey(user.Email)]; ok {
+		return NewUserType.User{}, ErrEmailTaken
+	}
+	user.ID = r.nextID
+	r.put(user)
+	return user, nil
+}
+
+func (r *MemoryUserRepository) Update(user NewUserType.User) (NewUserType.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.users[user.ID]
+	if !ok {
+		return NewUserType.User{}, ErrUserNotFound
+	}
+	if id, ok := r.byEmail[emailKey(user.Email)]; ok && id != user.ID {
+		return NewUserType.User{}, ErrEmailTaken
+	}
+	delete(r.byEmail, emailKey(old.Email))
+	r.put(user)
+	return user, nil
+}
+
+func (r *MemoryUserRepository) SetAvatar(id int, old, avatar string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if user.Avatar != old {
+		return ErrAvatarChanged
+	}
+	user.Avatar = avatar
+	r.users[id] = user
+	return nil
+}
+
+// put stores user under its own ID, callers must hold the lock
+func (r *MemoryUserRepository) put(user NewUserType.User) {
+	if _, ok := r.users[user.ID]; !ok {
+		r.order = append(r.order, user.ID)
+	}
+	r.users[user.ID] = user
+	r.byEmail[emailKey(user.Email)] = user.ID
+	if user.ID >= r.nextID {
+		r.nextID = user.ID + 1
+	}
+}
+
+func (r *MemoryUserRepository) Get(id int) (NewUserType.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return NewUserType.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *MemoryUserRepository) GetByEmail(email string) (NewUserType.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byEmail[emailKey(email)]
+	if !ok {
+		return NewUserType.User{}, ErrUserNotFound
+	}
+	return r.users[id], nil
+}
+
+func (r *MemoryUserRepository) List() ([]NewUserType.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]NewUserType.User, 0, len(r.order))
+	for _, id := range r.order {
+		list = append(list, r.users[id])
+	}
+	return list, nil
+}
+
+func (r *MemoryUserRepository) Find(f filter.Filter) ([]NewUserType.User, error) {
+	list, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]NewUserType.User, 0, len(list))
+	for _, user := range list {
+		if f.Match(user) {
+			matched = append(matched, user)
+		}
+	}
+	slices.SortStableFunc(matched, f.Compare)
+	return matched, nil
+}
+
+// memoryState is a copy of everything in a MemoryUserRepository
+type memoryState struct {
+	users   map[int]NewUserType.User
+	byEmail map[string]int
+	order   []int
+	nextID  int
+}
+
+func (r *MemoryUserRepository) state() memoryState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return memoryState{users: maps.Clone(r.users), byEmail: maps.Clone(r.byEmail), order: slices.Clone(r.order), nextID: r.nextID}
+}
+
+// restore puts back a state taken earlier
+func (r *MemoryUserRepository) restore(st memoryState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users, r.byEmail, r.order, r.nextID = st.users, st.byEmail, st.order, st.nextID
+}
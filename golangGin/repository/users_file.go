@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	NewUserType "myGoApp/types"
+
+	"github.com/SangamSilwal/httpkit/fileutil"
+)
+
+// storedUser adds the fields User hides from json back
+type storedUser struct {
+	NewUserType.User
+	PasswordHash string `json:"password_hash,omitempty"`
+	Avatar       string `json:"avatar,omitempty"`
+}
+
+// FileUserRepository keeps users in memory and writes a JSON snapshot
+// to disk after every change, so they survive a restart
+type FileUserRepository struct {
+	*MemoryUserRepository
+	path string
+	// writeMu keeps snapshots in the same order as the changes
+	writeMu sync.Mutex
+}
+
+// NewFileUserRepository loads path, a missing file means no users yet
+func NewFileUserRepository(path string) (*FileUserRepository, error) {
+	r := &FileUserRepository{MemoryUserRepository: NewMemoryUserRepository(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved []storedUser
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range saved {
+		user := s.User
+		user.PasswordHash = s.PasswordHash
+		user.Avatar = s.Avatar
+		r.put(user)
+	}
+	return r, nil
+}
+
+func (r *FileUserRepository) Create(user NewUserType.User) (NewUserType.User, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	before := r.state()
+	user, err := r.MemoryUserRepository.Create(user)
+	if err != nil {
+		return NewUserType.User{}, err
+	}
+	if err := r.commit(before); err != nil {
+		return NewUserType.User{}, err
+	}
+	return user, nil
+}
+
+func (r *FileUserRepository) Update(user NewUserType.User) (NewUserType.User, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	before := r.state()
+	user, err := r.MemoryUserRepository.Update(user)
+	if err != nil {
+		return NewUserType.User{}, err
+	}
+	if err := r.commit(before); err != nil {
+		return NewUserType.User{}, err
+	}
+	return user, nil
+}
+
+func (r *FileUserRepository) SetAvatar(id int, old, avatar string) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	before := r.state()
+	if err := r.MemoryUserRepository.SetAvatar(id, old, avatar); err != nil {
+		return err
+	}
+	return r.commit(before)
+}
+
+// commit saves the change just made in memory, or undoes it when the file
+// cannot be written, a user that was refused must not be able to log in
+func (r *FileUserRepository) commit(before memoryState) error {
+	if err := r.save(); err != nil {
+		r.restore(before)
+		return err
+	}
+	return nil
+}
+
+// save replaces the JSON file with the current users, it holds password
+// hashes so only the owner may read it
+func (r *FileUserRepository) save() error {
+	list, err := r.List()
+	if err != nil {
+		return err
+	}
+	saved := make([]storedUser, 0, len(list))
+	for _, user := range list {
+		saved = append(saved, storedUser{User: user, PasswordHash: user.PasswordHash, Avatar: user.Avatar})
+	}
+	data, err := json.MarshalIndent(saved, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return fileutil.WriteFileAtomic(r.path, data, 0o600)
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	NewUserType "myGoApp/types"
+)
+
+// testRepositories returns the memory and the file repository, the file one in a temp dir
+func testRepositories(t *testing.T) map[string]UserRepository {
+	t.Helper()
+	file, err := NewFileUserRepository(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]UserRepository{"memory": NewMemoryUserRepository(), "file": file}
+}
+
+func TestCreateAssignsIDs(t *testing.T) {
+	for kind, repo := range testRepositories(t) {
+		for i, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			// an id sent by the client is ignored
+			user, err := repo.Create(NewUserType.User{ID: 123, Name: "User", Email: email})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.ID != i+1 {
+				t.Errorf("%s: user %d got id %d", kind, i+1, user.ID)
+			}
+			got, err := repo.Get(user.ID)
+			if err != nil || got.Email != email {
+				t.Errorf("%s: Get(%d) = %+v, %v", kind, user.ID, got, err)
+			}
+		}
+		if _, err := repo.Get(99); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("%s: Get of an unknown id error = %v, want ErrUserNotFound", kind, err)
+		}
+		if _, err := repo.Update(NewUserType.User{ID: 99, Email: "x@example.com"}); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("%s: Update of an unknown id error = %v, want ErrUserNotFound", kind, err)
+		}
+	}
+}
+
+func TestEmailIsUniqueIgnoringCase(t *testing.T) {
+	for kind, repo := range testRepositories(t) {
+		sam, err := repo.Create(NewUserType.User{Name: "Sam", Email: "Sam@Example.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ana, err := repo.Create(NewUserType.User{Name: "Ana", Email: "ana@example.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := repo.Create(NewUserType.User{Name: "Sam 2", Email: " sam@EXAMPLE.com "}); !errors.Is(err, ErrEmailTaken) {
+			t.Errorf("%s: create with a taken email error = %v, want ErrEmailTaken", kind, err)
+		}
+		ana.Email = "SAM@example.com"
+		if _, err := repo.Update(ana); !errors.Is(err, ErrEmailTaken) {
+			t.Errorf("%s: update to a taken email error = %v, want ErrEmailTaken", kind, err)
+		}
+		// changing the case of your own email is fine
+		sam.Email = "sam@example.com"
+		if _, err := repo.Update(sam); err != nil {
+			t.Errorf("%s: update of the own email error = %v", kind, err)
+		}
+		if got, err := repo.GetByEmail("SAM@EXAMPLE.COM"); err != nil || got.ID != sam.ID {
+			t.Errorf("%s: GetByEmail = %+v, %v", kind, got, err)
+		}
+	}
+}
+
+func TestFileRepositoryReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	repo, err := NewFileUserRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		if _, err := repo.Create(NewUserType.User{Name: "User", Email: email, PasswordHash: "hash"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloaded, err := NewFileUserRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reloaded.GetByEmail("B@example.com"); err != nil || got.ID != 2 || got.PasswordHash != "hash" {
+		t.Errorf("after reload GetByEmail = %+v, %v", got, err)
+	}
+	if next, err := reloaded.Create(NewUserType.User{Email: "c@example.com"}); err != nil || next.ID != 3 {
+		t.Errorf("id after reload = %d, %v, want 3", next.ID, err)
+	}
+}
+
+func TestSetAvatarOnlyReplacesTheExpectedAvatar(t *testing.T) {
+	repos := testRepositories(t)
+	for kind, repo := range repos {
+		user, err := repo.Create(NewUserType.User{Name: "Sam", Email: "sam@example.com", Age: 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// a rename that happens while the avatar uploads
+		renamed := user
+		renamed.Name = "Sam S"
+		if _, err := repo.Update(renamed); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := repo.SetAvatar(user.ID, "", "1-a.png"); err != nil {
+			t.Fatalf("%s: SetAvatar error = %v", kind, err)
+		}
+		if err := repo.SetAvatar(user.ID, "", "1-b.png"); !errors.Is(err, ErrAvatarChanged) {
+			t.Errorf("%s: second upload from the same old avatar error = %v, want ErrAvatarChanged", kind, err)
+		}
+		if err := repo.SetAvatar(99, "", "99-a.png"); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("%s: unknown user error = %v, want ErrUserNotFound", kind, err)
+		}
+
+		got, err := repo.Get(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "Sam S" || got.Avatar != "1-a.png" {
+			t.Errorf("%s: user = %q with avatar %q, want Sam S with 1-a.png", kind, got.Name, got.Avatar)
+		}
+	}
+
+	reloaded, err := NewFileUserRepository(repos["file"].(*FileUserRepository).path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := reloaded.Get(1); got.Avatar != "1-a.png" {
+		t.Errorf("avatar after reload = %q, want 1-a.png", got.Avatar)
+	}
+}
+
+func TestFileRepositoryUndoesChangesItCannotSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewFileUserRepository(filepath.Join(dir, "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := repo.Create(NewUserType.User{Name: "Sam", Email: "sam@example.com", Age: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without the directory every save fails
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Create(NewUserType.User{Name: "Ana", Email: "ana@example.com"}); err == nil {
+		t.Fatal("create succeeded without a directory to save to")
+	}
+	renamed := kept
+	renamed.Email = "sam@new.example"
+	if _, err := repo.Update(renamed); err == nil {
+		t.Fatal("update succeeded without a directory to save to")
+	}
+	if err := repo.SetAvatar(kept.ID, "", "1-a.png"); err == nil {
+		t.Fatal("SetAvatar succeeded without a directory to save to")
+	}
+
+	list, _ := repo.List()
+	if len(list) != 1 || list[0].Email != kept.Email || list[0].Avatar != "" {
+		t.Errorf("after failed saves the repository has %+v, want only %+v", list, kept)
+	}
+	if _, err := repo.GetByEmail("sam@new.example"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("the unsaved email still finds a user: %v", err)
+	}
+
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ana, err := repo.Create(NewUserType.User{Name: "Ana", Email: "ana@example.com"})
+	if err != nil {
+		t.Fatalf("retry after the failed save: %v", err)
+	}
+	if ana.ID != 2 {
+		t.Errorf("ID after the undone create = %d, want 2", ana.ID)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"cmp"
+	"myGoApp/auth"
+	"myGoApp/controllers"
+	"net/http"
+
+	"github.com/SangamSilwal/httpkit/metrics"
+	"github.com/SangamSilwal/httpkit/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// Route is one row of the route table
+type Route struct {
+	// Version is v1, v2 ... and becomes the /api/<version> prefix, empty means no prefix
+	Version string
+	Method  string
+	Path    string
+	// Auth requires a valid access token, Roles additionally limits who may call it
+	Auth  bool
+	Roles []string
+	// Limit overrides the default rate limit of the route
+	Limit *ratelimit.Limiter
+	// MaxBody overrides the default body limit, negative leaves it to the handler
+	MaxBody int64
+	Handler gin.HandlerFunc
+}
+
+// legacyVersion routes are also served without the /api/<version> prefix,
+// clients written before versioning call /users and /filter directly
+const legacyVersion = "v1"
+
+// FullPath is the path the route is served at
+func (r Route) FullPath() string {
+	if r.Version == "" {
+		return r.Path
+	}
+	return "/api/" + r.Version + r.Path
+}
+
+// Paths is FullPath plus the unversioned alias of a legacyVersion route
+func (r Route) Paths() []string {
+	if r.Version == legacyVersion {
+		return []string{r.FullPath(), r.Path}
+	}
+	return []string{r.FullPath()}
+}
+
+// app holds what the controllers need
+type app struct {
+	issuer        *auth.Issuer
+	authenticator auth.Authenticator
+	// subjects reloads the user on refresh
+	subjects auth.SubjectLookup
+	users    *controllers.UserController
+	avatars  *controllers.AvatarController
+	// apiLimit applies to every route, loginLimit only to login and refresh,
+	// ipLimit guards the routes needing a token before the token is checked
+	apiLimit   *ratelimit.Limiter
+	ipLimit    *ratelimit.Limiter
+	loginLimit *ratelimit.Limiter
+	http       httpConfig
+	metrics    *metrics.Metrics
+}
+
+// routeTable lists every route of the service, registerRoutes serves exactly these
+func routeTable(a app) []Route {
+	home := controllers.HomeController{}
+	usersV2 := &controllers.UserControllerV2{UserController: a.users}
+	login := auth.LoginHandler(a.issuer, a.authenticator)
+	refresh := auth.RefreshHandler(a.issuer, a.subjects)
+
+	return []Route{
+		{Method: http.MethodGet, Path: "/", Handler: home.Index},
+		{Method: http.MethodGet, Path: "/metrics", Handler: gin.WrapH(a.metrics.Handler())},
+
+		{Version: "v1", Method: http.MethodPost, Path: "/auth/login", Limit: a.loginLimit, Handler: login},
+		{Version: "v1", Method: http.MethodPost, Path: "/auth/refresh", Limit: a.loginLimit, Handler: refresh},
+		{Version: "v1", Method: http.MethodGet, Path: "/users/data", Auth: true, Handler: a.users.List},
+		{Version: "v1", Method: http.MethodPost, Path: "/users", Auth: true, Roles: []string{"admin"}, Handler: a.users.Create},
+		{Version: "v1", Method: http.MethodGet, Path: "/users/:id", Auth: true, Handler: a.users.Get},
+		{Version: "v1", Method: http.MethodPost, Path: "/users/:id/avatar", Auth: true, MaxBody: -1, Handler: a.avatars.Upload},
+		{Version: "v1", Method: http.MethodGet, Path: "/users/:id/avatar", Handler: a.avatars.Get},
+		{Version: "v1", Method: http.MethodGet, Path: "/filter", Auth: true, Handler: a.users.Filter},
+
+		{Version: "v2", Method: http.MethodPost, Path: "/auth/login", Limit: a.loginLimit, Handler: login},
+		{Version: "v2", Method: http.MethodPost, Path: "/auth/refresh", Limit: a.loginLimit, Handler: refresh},
+		{Version: "v2", Method: http.MethodGet, Path: "/users", Auth: true, Handler: usersV2.List},
+		{Version: "v2", Method: http.MethodPost, Path: "/users", Auth: true, Roles: []string{"admin"}, Handler: usersV2.Create},
+		{Version: "v2", Method: http.MethodGet, Path: "/users/:id", Auth: true, Handler: usersV2.Get},
+		{Version: "v2", Method: http.MethodPost, Path: "/users/:id/avatar", Auth: true, MaxBody: -1, Handler: a.avatars.Upload},
+		{Version: "v2", Method: http.MethodGet, Path: "/users/:id/avatar", Handler: a.avatars.Get},
+	}
+}
+
+// registerRoutes adds the table to router at every path in Route.Paths
+// routes without their own Limit or MaxBody get the defaults from a
+func registerRoutes(router *gin.Engine, table []Route, a app) {
+	for _, r := range table {
+
+		var handlers []gin.HandlerFunc
+		if r.Auth {
+			if a.ipLimit != nil {
+				handlers = append(handlers, ipRateLimit(a.ipLimit))
+			}
+			handlers = append(handlers, auth.RequireAuth(a.issuer))
+		}
+		if limit := cmp.Or(r.Limit, a.apiLimit); limit != nil {
+			handlers = append(handlers, rateLimit(limit))
+		}
+		if maxBody := cmp.Or(r.MaxBody, a.http.maxBodyBytes); maxBody > 0 {
+			handlers = append(handlers, bodyLimit(maxBody))
+		}
+		if len(r.Roles) > 0 {
+			handlers = append(handlers, auth.RequireRole(r.Roles...))
+		}
+		handlers = append(handlers, r.Handler)
+		for _, path := range r.Paths() {
+			router.Handle(r.Method, path, handlers...)
+		}
+	}
+}
+
+// newRouter builds the gin engine with every route of the table
+func newRouter(a app) *gin.Engine {
+	//This gin.Default set up a router with logger abd recovery middleware attached
+	router := gin.Default()
+	router.HandleMethodNotAllowed = true
+	// ClientIP keys the rate limits, so X-Forwarded-For from clients must not count
+	router.SetTrustedProxies(nil)
+	router.Use(recordRoute)
+	router.NoRoute(controllers.NoRoute)
+	router.NoMethod(controllers.NoMethod)
+
+	registerRoutes(router, routeTable(a), a)
+	return router
+}
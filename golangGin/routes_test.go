@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"myGoApp/auth"
+	"myGoApp/controllers"
+	"myGoApp/repository"
+	"myGoApp/validation"
+
+	"github.com/SangamSilwal/httpkit/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+func testApp(t *testing.T) app {
+	t.Helper()
+	issuer, err := auth.NewIssuer(auth.Config{Key: auth.RandomKey(), Issuer: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := &controllers.UserController{Repo: repository.NewMemoryUserRepository()}
+	return app{
+		issuer:        issuer,
+		authenticator: auth.Chain{},
+		subjects:      auth.Chain{},
+		users:         users,
+		avatars:       &controllers.AvatarController{Users: users},
+		metrics:       metrics.New(),
+	}
+}
+
+// TestRouterServesTheRouteTable checks both ways that gin serves exactly the table
+func TestRouterServesTheRouteTable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := testApp(t)
+	router := newRouter(a)
+
+	var want []string
+	for _, r := range routeTable(a) {
+		for _, path := range r.Paths() {
+			want = append(want, r.Method+" "+path)
+		}
+	}
+	var got []string
+	for _, r := range router.Routes() {
+		got = append(got, r.Method+" "+r.Path)
+	}
+
+	for _, key := range want {
+		if !slices.Contains(got, key) {
+			t.Errorf("%s is in the route table but not served", key)
+		}
+	}
+	for _, key := range got {
+		if !slices.Contains(want, key) {
+			t.Errorf("%s is served but not in the route table", key)
+		}
+	}
+}
+
+// TestLegacyPathsStayServed guards the paths clients used before /api/v1 existed
+func TestLegacyPathsStayServed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got []string
+	for _, r := range newRouter(testApp(t)).Routes() {
+		got = append(got, r.Method+" "+r.Path)
+	}
+	for _, key := range []string{
+		"GET /users/data", "POST /users", "GET /users/:id", "GET /filter",
+		"POST /auth/login", "POST /auth/refresh",
+	} {
+		if !slices.Contains(got, key) {
+			t.Errorf("%s is no longer served", key)
+		}
+	}
+}
+
+// TestFilterNeedsAToken, /filter answers with every field of the matching users
+func TestFilterNeedsAToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := newRouter(testApp(t))
+	for _, path := range []string{"/filter", "/api/v1/filter", "/filter?age>=0"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s without a token = %d, want 401", path, rec.Code)
+		}
+	}
+}
+
+// TestCreateUserNeedsAdmin, the user role may read users but not create them
+func TestCreateUserNeedsAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := testApp(t)
+	router := newRouter(a)
+	body := `{"name": "Sam", "email": "sam@example.com", "age": 20}`
+
+	for role, want := range map[string]int{"": http.StatusUnauthorized, "user": http.StatusForbidden} {
+		for _, path := range []string{"/users", "/api/v1/users", "/api/v2/users"} {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if role != "" {
+				pair, err := a.issuer.Issue(auth.Subject{ID: "1", Role: role})
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != want {
+				t.Errorf("POST %s as %q = %d, want %d", path, role, rec.Code, want)
+			}
+		}
+	}
+}
+
+// TestUsersAreStored goes through POST and GET /users like a client,
+// ids come from the repository and emails are unique ignoring case
+func TestUsersAreStored(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := validation.Setup(validation.DefaultRules()); err != nil {
+		t.Fatal(err)
+	}
+	a := testApp(t)
+	router := newRouter(a)
+	admin, err := a.issuer.Issue(auth.Subject{ID: "1", Role: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+admin.AccessToken)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	user := `{"id": 123, "name": "Sam", "email": "%s", "age": 30, "role": "user", "password": "Secret123"}`
+
+	rec := serve(http.MethodPost, "/api/v1/users", fmt.Sprintf(user, "sam@example.com"))
+	if rec.Code != http.StatusCreated || rec.Header().Get("Location") != "/api/v1/users/1" {
+		t.Fatalf("create = %d %s, Location %q", rec.Code, rec.Body, rec.Header().Get("Location"))
+	}
+	if rec := serve(http.MethodPost, "/api/v1/users", fmt.Sprintf(user, "SAM@example.com")); rec.Code != http.StatusConflict {
+		t.Errorf("create with the same email in upper case = %d, want 409", rec.Code)
+	}
+
+	rec = serve(http.MethodGet, "/api/v1/users/1", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"email":"sam@example.com"`) {
+		t.Errorf("GET /users/1 = %d %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "Secret123") || strings.Contains(rec.Body.String(), "$2a$") {
+		t.Error("the password or its hash is in the response")
+	}
+	for path, want := range map[string]int{
+		"/api/v1/users/2":   http.StatusNotFound,
+		"/users/999":        http.StatusNotFound,
+		"/api/v2/users/999": http.StatusNotFound,
+		"/api/v1/users/abc": http.StatusBadRequest,
+	} {
+		if rec := serve(http.MethodGet, path, ""); rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
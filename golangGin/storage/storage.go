@@ -0,0 +1,118 @@
+// Package storage keeps uploaded files, handlers only see the Store interface
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("file not found")
+	ErrInvalidName = errors.New("invalid file name")
+)
+
+// File is an opened stored file, ready for http.ServeContent
+type File interface {
+	io.ReadSeekCloser
+}
+
+// Store saves and serves files by name, names come from NewName
+type Store interface {
+	Put(name string, r io.Reader) error
+	// Open also returns the modification time for Last-Modified
+	Open(name string) (File, time.Time, error)
+	Delete(name string) error
+}
+
+// names are only ever generated by NewName, anything else is refused
+// so a name can never walk out of the storage directory
+var validName = regexp.MustCompile(`^[a-z0-9]+-[0-9a-f]{32}\.[a-z0-9]{1,8}$`)
+
+// NewName builds a random file name like <prefix>-<32 hex>.png, avatars
+// use the user id as prefix, the uploaded file name is never used
+func NewName(prefix, ext string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return prefix + "-" + hex.EncodeToString(b) + ext
+}
+
+// LocalStore keeps files in one directory on disk
+type LocalStore struct {
+	Dir string
+}
+
+// NewLocalStore creates dir when it does not exist yet
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{Dir: dir}, nil
+}
+
+func (s *LocalStore) path(name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(s.Dir, name), nil
+}
+
+// Put writes to a temp file first, a half written upload is never visible
+func (s *LocalStore) Put(name string, r io.Reader) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalStore) Open(name string) (File, time.Time, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, time.Time{}, err
+	}
+	return f, info.ModTime(), nil
+}
+
+func (s *LocalStore) Delete(name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
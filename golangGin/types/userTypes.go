@@ -1,9 +1,25 @@
 package NewUserType
 
 type User struct {
+	// ID is assigned by the repository, a value sent by the client is ignored
+	ID    int    `json:"id"`
 	Name  string `json:"name" binding:"required"`
-	Email string `json:"email" binding:"required,email"`
+	Email string `json:"email" binding:"required,email,allowed_email_domain"`
 	Age   int    `json:"age" binding:"required,min=18,max=100"`
-	Phone string `json:"phone"`
-	Role  string `json:"role" binding:"required,oneof=admin user guest"`
+	Phone string `json:"phone" binding:"omitempty,e164phone"`
+	// role, e164phone and allowed_email_domain are registered in the validation package
+	Role     string   `json:"role" binding:"required,role"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	// Avatar is the stored file name, set by the avatar upload and never bound from a request
+	Avatar string `json:"-"`
+	// PasswordHash is the bcrypt hash, json:"-" keeps it out of every response
+	PasswordHash string `json:"-"`
+}
+
+// CreateUserRequest is the body of POST /users, the plain password
+// is only accepted here and never stored or sent back
+type CreateUserRequest struct {
+	User
+	Password string `json:"password" binding:"required,min=8,max=72"`
 }
@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// Rules are the business rules behind the custom binding tags,
+// they come from a JSON file so changing them needs no code change
+type Rules struct {
+	// Roles is what the role tag accepts
+	Roles []string `json:"roles"`
+	// DisallowedEmailDomains are refused by the allowed_email_domain tag, subdomains too
+	DisallowedEmailDomains []string `json:"disallowed_email_domains"`
+}
+
+// DefaultRules is used when no rules file is given
+func DefaultRules() Rules {
+	return Rules{
+		Roles:                  []string{"admin", "user", "guest"},
+		DisallowedEmailDomains: []string{"mailinator.com", "guerrillamail.com", "10minutemail.com"},
+	}
+}
+
+// LoadRules reads a rules file, fields missing in the file keep their default
+func LoadRules(path string) (Rules, error) {
+	rules := DefaultRules()
+	if path == "" {
+		return rules, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, err
+	}
+	return rules, nil
+}
+
+// e164 is + followed by up to 15 digits, the first one not 0
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// IsE164Phone reports whether phone looks like +9779812345678
+func IsE164Phone(phone string) bool {
+	return e164.MatchString(phone)
+}
+
+// EmailDomainAllowed reports whether the domain of email is not on the disallowed list
+func (r Rules) EmailDomainAllowed(email string) bool {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		// not an email at all, the email tag reports that
+		return true
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, bad := range r.DisallowedEmailDomains {
+		bad = strings.ToLower(bad)
+		if domain == bad || strings.HasSuffix(domain, "."+bad) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidRole reports whether role is one of the configured roles
+func (r Rules) ValidRole(role string) bool {
+	return slices.Contains(r.Roles, role)
+}
+
+// customTag is a binding tag together with its messages
+type customTag struct {
+	name string
+	fn   validator.Func
+	// messages by locale, {0} is the field and {1} the extra param
+	messages map[string]string
+	param    string
+}
+
+func (r Rules) tags() []customTag {
+	return []customTag{
+		{
+			name: "e164phone",
+			fn:   func(fl validator.FieldLevel) bool { return IsE164Phone(fl.Field().String()) },
+			messages: map[string]string{
+				"en": "{0} must be a phone number in E.164 format, like +9779812345678",
+				"es": "{0} debe ser un número de teléfono en formato E.164, como +9779812345678",
+			},
+		},
+		{
+			name: "allowed_email_domain",
+			fn:   func(fl validator.FieldLevel) bool { return r.EmailDomainAllowed(fl.Field().String()) },
+			messages: map[string]string{
+				"en": "{0} uses an email domain that is not allowed",
+				"es": "{0} usa un dominio de correo que no está permitido",
+			},
+		},
+		{
+			name: "role",
+			fn:   func(fl validator.FieldLevel) bool { return r.ValidRole(fl.Field().String()) },
+			messages: map[string]string{
+				"en": "{0} must be one of [{1}]",
+				"es": "{0} debe ser uno de [{1}]",
+			},
+			param: strings.Join(r.Roles, " "),
+		},
+	}
+}
+
+// registerRules adds the custom tags and their messages to v
+func registerRules(v *validator.Validate, rules Rules) error {
+	for _, tag := range rules.tags() {
+		if err := v.RegisterValidation(tag.name, tag.fn); err != nil {
+			return err
+		}
+		for locale, msg := range tag.messages {
+			trans, found := uni.GetTranslator(locale)
+			if !found {
+				continue
+			}
+			param := tag.param
+			err := v.RegisterTranslation(tag.name, trans,
+				func(t ut.Translator) error { return t.Add(tag.name, msg, true) },
+				func(t ut.Translator, fe validator.FieldError) string {
+					s, _ := t.T(fe.Tag(), fe.Field(), param)
+					return s
+				})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestIsE164Phone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  bool
+	}{
+		{"+9779812345678", true},
+		{"+1234567", true},
+		{"+123456789012345", true},
+		{"+123456", false},
+		{"+1234567890123456", false},
+		{"+0123456789", false},
+		{"9779812345678", false},
+		{"+977 981234567", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsE164Phone(tt.phone); got != tt.want {
+			t.Errorf("IsE164Phone(%q) = %v, want %v", tt.phone, got, tt.want)
+		}
+	}
+}
+
+func TestEmailDomainAllowed(t *testing.T) {
+	rules := Rules{DisallowedEmailDomains: []string{"mailinator.com", "Spam.Example"}}
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"sam@gmail.com", true},
+		{"sam@mailinator.com", false},
+		{"sam@MAILINATOR.com", false},
+		{"sam@spam.example", false},
+		{"sam@eu.mailinator.com", false},
+		{"sam@notmailinator.com", true},
+		{"sam@mailinator.com.np", true},
+		{"not an email", true},
+	}
+	for _, tt := range tests {
+		if got := rules.EmailDomainAllowed(tt.email); got != tt.want {
+			t.Errorf("EmailDomainAllowed(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRulesKeepsDefaultsForMissingFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(`{"roles": ["owner", "member"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"owner", "member"}; !reflect.DeepEqual(rules.Roles, want) {
+		t.Errorf("Roles = %v, want %v", rules.Roles, want)
+	}
+	if want := DefaultRules().DisallowedEmailDomains; !reflect.DeepEqual(rules.DisallowedEmailDomains, want) {
+		t.Errorf("DisallowedEmailDomains = %v, want the defaults %v", rules.DisallowedEmailDomains, want)
+	}
+
+	if _, err := LoadRules(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadRules of a missing file did not fail")
+	}
+}
+
+// TestRoleMessageListsConfiguredRoles goes through gin's validator like a handler does
+func TestRoleMessageListsConfiguredRoles(t *testing.T) {
+	if err := Setup(Rules{Roles: []string{"owner", "member"}}); err != nil {
+		t.Fatal(err)
+	}
+	var req struct {
+		Role string `json:"role" binding:"role"`
+	}
+
+	req.Role = "member"
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		t.Fatalf("role %q was refused: %v", req.Role, err)
+	}
+
+	req.Role = "admin"
+	err := binding.Validator.ValidateStruct(&req)
+	for accept, want := range map[string]string{
+		"en":    "role must be one of [owner member]",
+		"es-ES": "role debe ser uno de [owner member]",
+	} {
+		trans, _ := Translator(accept)
+		errs, ok := FieldErrors(err, trans)
+		if !ok || len(errs) != 1 {
+			t.Fatalf("FieldErrors(%v) = %v, %v", err, errs, ok)
+		}
+		if errs[0].Field != "role" || errs[0].Message != want {
+			t.Errorf("%s: got %s %q, want role %q", accept, errs[0].Field, errs[0].Message, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package validation turns gin binding errors into per-field messages
+// in the language the client asks for with Accept-Language
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/SangamSilwal/httpkit/middleware"
+	"github.com/SangamSilwal/httpkit/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is used when Accept-Language names nothing we support
+const DefaultLocale = "en"
+
+var uni *ut.UniversalTranslator
+
+// fallback is used while Setup has not run, in handler tests for example,
+// validator errors then keep their default English text
+var fallback = sync.OnceValue(func() ut.Translator {
+	english := en.New()
+	trans, _ := ut.New(english, english).GetTranslator(DefaultLocale)
+	trans.Add("type_mismatch", "{0} must be a {1}", false)
+	return trans
+})
+
+// Setup makes gin's validator report json field names, registers the
+// custom tags from rules and the English and Spanish messages,
+// call it once before serving
+func Setup(rules Rules) error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("gin binding validator is not go-playground/validator")
+	}
+	v.RegisterTagNameFunc(jsonName)
+
+	english := en.New()
+	uni = ut.New(english, english, es.New())
+
+	enTrans, _ := uni.GetTranslator("en")
+	if err := en_translations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return err
+	}
+	esTrans, _ := uni.GetTranslator("es")
+	if err := es_translations.RegisterDefaultTranslations(v, esTrans); err != nil {
+		return err
+	}
+
+	// a wrong json type never reaches the validator, so it needs its own message
+	if err := enTrans.Add("type_mismatch", "{0} must be a {1}", false); err != nil {
+		return err
+	}
+	if err := esTrans.Add("type_mismatch", "{0} debe ser de tipo {1}", false); err != nil {
+		return err
+	}
+	return registerRules(v, rules)
+}
+
+// jsonName makes FieldError.Field() return the json key, age instead of Age
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// Translator picks the best supported locale from an Accept-Language header
+func Translator(acceptLanguage string) (ut.Translator, string) {
+	if uni == nil {
+		return fallback(), DefaultLocale
+	}
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	locales := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		base, _ := tag.Base()
+		locales = append(locales, base.String())
+	}
+
+	trans, found := uni.FindTranslator(locales...)
+	if !found {
+		trans, _ = uni.GetTranslator(DefaultLocale)
+	}
+	return trans, trans.Locale()
+}
+
+// FieldErrors maps a binding error to one message per failing field
+// ok is false when err is not about fields, like malformed JSON
+func FieldErrors(err error, trans ut.Translator) (errs []problem.FieldError, ok bool) {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		for _, fe := range verrs {
+			errs = append(errs, problem.FieldError{Field: fe.Field(), Message: fe.Translate(trans)})
+		}
+		return errs, true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		msg, _ := trans.T("type_mismatch", typeErr.Field, jsonTypeName(typeErr.Type))
+		return []problem.FieldError{{Field: typeErr.Field, Message: msg}}, true
+	}
+	return nil, false
+}
+
+// jsonTypeName names the json type a Go type expects
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map, reflect.Pointer:
+		return "object"
+	default:
+		return t.Kind().String()
+	}
+}
+
+// AbortWithBindError answers a failed ShouldBind, field errors become a 422
+// with messages in the client's Accept-Language, a body over the limit a 413
+// and anything else a 400
+func AbortWithBindError(c *gin.Context, err error) {
+	c.Abort()
+	if limit, ok := middleware.TooLarge(err); ok {
+		middleware.WriteTooLarge(c.Writer, c.Request, limit)
+		return
+	}
+	trans, locale := Translator(c.GetHeader("Accept-Language"))
+	fields, ok := FieldErrors(err, trans)
+	if !ok {
+		problem.Error(c.Writer, c.Request, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Header("Content-Language", locale)
+	// Add, not Set, the CORS middleware already put Origin in Vary
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	problem.Write(c.Writer, c.Request, problem.New(http.StatusUnprocessableEntity, "One or more fields are invalid").
+		WithErrors(fields...))
+}
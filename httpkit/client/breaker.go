@@ -0,0 +1,230 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending anything while the breaker of a host is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State of the breaker of one host
+type State int
+
+const (
+	// StateClosed lets every request through and counts failures
+	StateClosed State = iota
+	// StateOpen fails every request right away until the cooldown is over
+	StateOpen
+	// StateHalfOpen lets a few probe requests through to see if the host is back
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// StateChange is passed to BreakerConfig.OnStateChange
+type StateChange struct {
+	Host     string
+	From, To State
+	At       time.Time
+}
+
+// BreakerConfig controls the circuit breaker the Client keeps per host
+type BreakerConfig struct {
+	// Window is how long failures are counted before the counts start over
+	Window time.Duration
+	// MinRequests in a window before the breaker may open, so one early failure does not trip it
+	MinRequests int
+	// FailureRatio of failed requests in a window that opens the breaker
+	FailureRatio float64
+	// Cooldown is how long the breaker stays open before probing
+	Cooldown time.Duration
+	// Probes is how many requests half-open lets through, all of them must succeed to close again
+	Probes int
+	// OnStateChange is called after every transition, outside the breaker's lock
+	OnStateChange func(StateChange)
+}
+
+// DefaultBreakerConfig opens when half of at least 10 requests in 30s fail
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Window:       30 * time.Second,
+		MinRequests:  10,
+		FailureRatio: 0.5,
+		Cooldown:     15 * time.Second,
+		Probes:       1,
+	}
+}
+
+// breaker is the state machine for one host
+type breaker struct {
+	host string
+	cfg  *BreakerConfig
+
+	mu          sync.Mutex
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	// openedAt is when the breaker last opened
+	openedAt time.Time
+	// probes in flight and probes that succeeded while half-open
+	probing   int
+	succeeded int
+	// generation goes up with every state change, a result from an
+	// older generation belongs to a request sent before that change
+	generation uint64
+}
+
+// allow reports whether a request may be sent now,
+// the generation it returns has to be handed back to record
+func (b *breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	now := time.Now()
+	var change *StateChange
+	defer func() {
+		b.mu.Unlock()
+		b.notify(change)
+	}()
+
+	switch b.state {
+	case StateOpen:
+		wait := b.cfg.Cooldown - now.Sub(b.openedAt)
+		if wait > 0 {
+			return 0, fmt.Errorf("%w for %s, retry in %v", ErrCircuitOpen, b.host, wait.Round(time.Millisecond))
+		}
+		change = b.moveTo(StateHalfOpen, now)
+		fallthrough
+	case StateHalfOpen:
+		if b.probing+b.succeeded >= b.cfg.Probes {
+			return 0, fmt.Errorf("%w for %s, waiting for probe requests", ErrCircuitOpen, b.host)
+		}
+		b.probing++
+	default:
+		if now.Sub(b.windowStart) >= b.cfg.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+	}
+	return b.generation, nil
+}
+
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeIgnored
+)
+
+// record counts the result of a request allow let through in generation gen
+func (b *breaker) record(gen uint64, result outcome) {
+	b.mu.Lock()
+	now := time.Now()
+	var change *StateChange
+	defer func() {
+		b.mu.Unlock()
+		b.notify(change)
+	}()
+
+	// the request was sent before the last state change, a slow request from
+	// the closed state must not be taken for the probe of the half-open state
+	if gen != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateHalfOpen:
+		b.probing--
+		switch result {
+		case outcomeIgnored:
+			return
+		case outcomeFailure:
+			change = b.moveTo(StateOpen, now)
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.cfg.Probes {
+			change = b.moveTo(StateClosed, now)
+		}
+	case StateClosed:
+		if result == outcomeIgnored {
+			return
+		}
+		b.requests++
+		if result == outcomeFailure {
+			b.failures++
+		}
+		if b.requests >= b.cfg.MinRequests && float64(b.failures) >= b.cfg.FailureRatio*float64(b.requests) {
+			change = b.moveTo(StateOpen, now)
+		}
+	}
+}
+
+// moveTo switches state and resets the counters, callers must hold the lock
+func (b *breaker) moveTo(to State, now time.Time) *StateChange {
+	change := &StateChange{Host: b.host, From: b.state, To: to, At: now}
+	b.state = to
+	b.windowStart, b.requests, b.failures = now, 0, 0
+	b.probing, b.succeeded = 0, 0
+	b.generation++
+	if to == StateOpen {
+		b.openedAt = now
+	}
+	return change
+}
+
+func (b *breaker) notify(change *StateChange) {
+	if change != nil && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(*change)
+	}
+}
+
+// breakers keeps one breaker per host, created on first use
+type breakers struct {
+	cfg    BreakerConfig
+	mu     sync.Mutex
+	byHost map[string]*breaker
+}
+
+func newBreakers(cfg BreakerConfig) *breakers {
+	def := DefaultBreakerConfig()
+	if cfg.Window <= 0 {
+		cfg.Window = def.Window
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = def.MinRequests
+	}
+	if cfg.FailureRatio <= 0 {
+		cfg.FailureRatio = def.FailureRatio
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = def.Cooldown
+	}
+	if cfg.Probes <= 0 {
+		cfg.Probes = def.Probes
+	}
+	return &breakers{cfg: cfg, byHost: make(map[string]*breaker)}
+}
+
+func (bs *breakers) get(host string) *breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b, ok := bs.byHost[host]
+	if !ok {
+		b = &breaker{host: host, cfg: &bs.cfg, windowStart: time.Now()}
+		bs.byHost[host] = b
+	}
+	return b
+}
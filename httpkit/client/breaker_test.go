@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensProbesAndCloses(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if down.Load() {
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var changes []string
+	cfg := DefaultConfig()
+	cfg.BaseURL = srv.URL
+	cfg.Breaker = &BreakerConfig{
+		MinRequests:  4,
+		FailureRatio: 0.5,
+		Cooldown:     50 * time.Millisecond,
+		OnStateChange: func(c StateChange) {
+			mu.Lock()
+			changes = append(changes, c.From.String()+">"+c.To.String())
+			mu.Unlock()
+		},
+	}
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func() error {
+		res, err := c.Get(context.Background(), "/")
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	for range 4 {
+		if err := get(); StatusCode(err) != 500 {
+			t.Fatalf("err = %v, want 500 while closed", err)
+		}
+	}
+	host := srv.Listener.Addr().String()
+	if s := c.State(host); s != StateOpen {
+		t.Fatalf("state = %v after 4 failures, want open", s)
+	}
+
+	// open fails fast without reaching the server
+	if err := get(); !errors.Is(err, ErrCircuitOpen) || calls.Load() != 4 {
+		t.Fatalf("err = %v after %d calls, want ErrCircuitOpen and no new call", err, calls.Load())
+	}
+
+	// a failed probe opens it again
+	time.Sleep(60 * time.Millisecond)
+	if err := get(); StatusCode(err) != 500 || c.State(host) != StateOpen {
+		t.Fatalf("probe: err = %v state = %v, want 500 and open", err, c.State(host))
+	}
+
+	// a good probe closes it
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if err := get(); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.State(host); s != StateClosed {
+		t.Fatalf("state = %v after a good probe, want closed", s)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("changes = %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestBreakerIgnoresClientErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = srv.URL
+	cfg.Breaker = &BreakerConfig{MinRequests: 2}
+	c, _ := New(cfg)
+	for range 5 {
+		if _, err := c.Get(context.Background(), "/"); StatusCode(err) != http.StatusNotFound {
+			t.Fatalf("err = %v, want 404", err)
+		}
+	}
+	if s := c.State(srv.Listener.Addr().String()); s != StateClosed {
+		t.Errorf("state = %v, 404s must not open the breaker", s)
+	}
+}
+
+func TestBreakerIgnoresResultsFromBeforeAStateChange(t *testing.T) {
+	b := newBreakers(BreakerConfig{MinRequests: 2, FailureRatio: 0.5, Cooldown: time.Millisecond}).get("api")
+
+	// a slow request admitted while closed
+	slow, err := b.allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		gen, _ := b.allow()
+		b.record(gen, outcomeFailure)
+	}
+	if b.state != StateOpen {
+		t.Fatalf("state = %v, want open", b.state)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	probe, err := b.allow()
+	if err != nil || b.state != StateHalfOpen {
+		t.Fatalf("probe: err = %v state = %v, want half-open", err, b.state)
+	}
+
+	// the slow request finishing must not decide for the probe
+	b.record(slow, outcomeSuccess)
+	if b.state != StateHalfOpen || b.probing != 1 {
+		t.Fatalf("after the old result: state = %v probing = %d, want half-open with the probe in flight", b.state, b.probing)
+	}
+
+	b.record(probe, outcomeFailure)
+	if b.state != StateOpen {
+		t.Fatalf("state = %v after the probe failed, want open", b.state)
+	}
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config is how a Client talks to one API
+type Config struct {
+	// BaseURL is joined with relative paths, absolute URLs are used as they are
+	BaseURL string
+	// Timeout bounds the whole exchange, including reading the body
+	Timeout time.Duration
+	// DialTimeout, TLSHandshakeTimeout and ResponseHeaderTimeout bound the single steps
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	// Header is sent with every request, a header set on the request wins
+	Header    http.Header
+	UserAgent string
+	// Transport replaces the default transport, the step timeouts are then ignored
+	Transport http.RoundTripper
+	// Retry is applied by Do, the zero value sends every request once
+	Retry RetryPolicy
+	// Breaker turns on a circuit breaker per host, nil means none
+	Breaker *BreakerConfig
+}
+
+// DefaultConfig has timeouts that suit calls to public APIs
+func DefaultConfig() Config {
+	return Config{
+		Timeout:               30 * time.Second,
+		DialTimeout:           5 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		UserAgent:             "SangamSilwal-GoLang/1.0",
+	}
+}
+
+// Client wraps http.Client, unlike http.Get it never shares the
+// default client and answers every status outside 2xx with a *StatusError
+type Client struct {
+	base      *url.URL
+	http      *http.Client
+	header    http.Header
+	userAgent string
+	retry     RetryPolicy
+	breakers  *breakers
+}
+
+// New builds a Client, the only error is a BaseURL that does not parse
+func New(cfg Config) (*Client, error) {
+	c := &Client{header: cfg.Header.Clone(), userAgent: cfg.UserAgent, retry: cfg.Retry}
+	if cfg.BaseURL != "" {
+		base, err := url.Parse(cfg.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("client: base url: %w", err)
+		}
+		if base.Scheme == "" || base.Host == "" {
+			return nil, fmt.Errorf("client: base url %q must be absolute", cfg.BaseURL)
+		}
+		c.base = base
+	}
+
+	transport := cfg.Transport
+	if transport == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.DialContext = (&net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+		t.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+		t.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+		transport = t
+	}
+	c.http = &http.Client{Transport: transport, Timeout: cfg.Timeout}
+	if cfg.Breaker != nil {
+		c.breakers = newBreakers(*cfg.Breaker)
+	}
+	return c, nil
+}
+
+// resolve joins ref with the base URL, a leading slash in ref stays below the
+// base path, so /users on https://host/api/v1 is https://host/api/v1/users
+func (c *Client) resolve(ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("client: url %q: %w", ref, err)
+	}
+	if u.IsAbs() {
+		return u.String(), nil
+	}
+	if c.base == nil {
+		return "", fmt.Errorf("client: url %q is not absolute and there is no base url", ref)
+	}
+	base := *c.base
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	u.Path = strings.TrimPrefix(u.Path, "/")
+	return base.ResolveReference(u).String(), nil
+}
+
+// NewRequest builds a request for ref, which may be relative to the base URL,
+// and adds the default headers
+func (c *Client) NewRequest(ctx context.Context, method, ref string, body io.Reader) (*http.Request, error) {
+	target, err := c.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	return req, nil
+}
+
+// Do sends req, a response outside 2xx is read, closed and returned as *StatusError
+// failed attempts are repeated as the RetryPolicy says, on success the caller must close the body
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	for n := 1; ; n++ {
+		res, err := c.send(req)
+		if err == nil {
+			return res, nil
+		}
+
+		delay, retry := c.retry.decide(req, n, err)
+		if c.retry.OnAttempt != nil {
+			c.retry.OnAttempt(Attempt{Request: req, Number: n, Err: err, Retry: retry, Delay: delay})
+		}
+		if !retry {
+			return nil, err
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// State reports the breaker state of host, always closed without a breaker
+func (c *Client) State(host string) State {
+	if c.breakers == nil {
+		return StateClosed
+	}
+	b := c.breakers.get(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// send is one attempt, it goes through the breaker of the host when there is one
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.breakers == nil {
+		return c.roundTrip(req)
+	}
+	b := c.breakers.get(req.URL.Host)
+	gen, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.roundTrip(req)
+	switch {
+	case req.Context().Err() != nil:
+		// the caller gave up, that says nothing about the host
+		b.record(gen, outcomeIgnored)
+	case err != nil && (StatusCode(err) == 0 || StatusCode(err) >= 500):
+		b.record(gen, outcomeFailure)
+	default:
+		// a 4xx is the caller's mistake, the host itself answered fine
+		b.record(gen, outcomeSuccess)
+	}
+	return res, err
+}
+
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		return nil, newStatusError(req, res)
+	}
+	return res, nil
+}
+
+// Get sends a GET for ref
+func (c *Client) Get(ctx context.Context, ref string) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, ref, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Post sends body with the given Content-Type
+func (c *Client) Post(ctx context.Context, ref, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, http.MethodPost, ref, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// PostForm sends data url encoded, like http.PostForm
+func (c *Client) PostForm(ctx context.Context, ref string, data url.Values) (*http.Response, error) {
+	return c.Post(ctx, ref, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+}
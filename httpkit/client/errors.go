@@ -0,0 +1,57 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxSnippet is how much of an error body StatusError keeps
+const maxSnippet = 512
+
+// StatusError is a response outside 2xx, Body holds the start of the response body
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+func newStatusError(req *http.Request, res *http.Response) *StatusError {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, maxSnippet+1))
+	body := strings.ToValidUTF8(string(b), "")
+	if len(b) > maxSnippet {
+		// the cut may split a rune, ToValidUTF8 drops the half
+		body = strings.ToValidUTF8(string(b[:maxSnippet]), "") + "..."
+	}
+	// drain a little more so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	return &StatusError{
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       strings.TrimSpace(body),
+	}
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// StatusCode returns the status of a *StatusError in err's chain, 0 when there is none
+func StatusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxJSONBytes caps how much of a JSON response is read
+const DefaultMaxJSONBytes = 10 << 20
+
+type jsonOptions struct {
+	maxBytes int64
+	strict   bool
+}
+
+// JSONOption changes how GetJSON and PostJSON decode the response
+type JSONOption func(*jsonOptions)
+
+// MaxBytes fails responses bigger than n bytes with ErrResponseTooLarge
+func MaxBytes(n int64) JSONOption {
+	return func(o *jsonOptions) { o.maxBytes = n }
+}
+
+// Strict fails on fields the target type does not have,
+// useful to notice when an API changed under us
+func Strict() JSONOption {
+	return func(o *jsonOptions) { o.strict = true }
+}
+
+// ErrResponseTooLarge means the body went past MaxBytes
+var ErrResponseTooLarge = errors.New("response body is too large")
+
+// DecodeError is a 2xx response whose body could not be decoded into the target type
+type DecodeError struct {
+	Method      string
+	URL         string
+	StatusCode  int
+	ContentType string
+	Err         error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s %s: decoding %d response (%s): %v", e.Method, e.URL, e.StatusCode, e.ContentType, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// GetJSON sends a GET for ref and decodes the JSON answer into a T
+func GetJSON[T any](ctx context.Context, c *Client, ref string, opts ...JSONOption) (T, error) {
+	var zero T
+	req, err := c.NewRequest(ctx, http.MethodGet, ref, nil)
+	if err != nil {
+		return zero, err
+	}
+	return DoJSON[T](c, req, opts...)
+}
+
+// PostJSON sends body as JSON and decodes the JSON answer into a Resp
+func PostJSON[Req, Resp any](ctx context.Context, c *Client, ref string, body Req, opts ...JSONOption) (Resp, error) {
+	return sendJSON[Req, Resp](ctx, c, http.MethodPost, ref, body, opts...)
+}
+
+// PutJSON is PostJSON with PUT
+func PutJSON[Req, Resp any](ctx context.Context, c *Client, ref string, body Req, opts ...JSONOption) (Resp, error) {
+	return sendJSON[Req, Resp](ctx, c, http.MethodPut, ref, body, opts...)
+}
+
+func sendJSON[Req, Resp any](ctx context.Context, c *Client, method, ref string, body Req, opts ...JSONOption) (Resp, error) {
+	var zero Resp
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return zero, fmt.Errorf("client: encoding %T: %w", body, err)
+	}
+	// a bytes.Reader lets NewRequest set GetBody, so retries can send it again
+	req, err := c.NewRequest(ctx, method, ref, bytes.NewReader(payload))
+	if err != nil {
+		return zero, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return DoJSON[Resp](c, req, opts...)
+}
+
+// DoJSON sends req and decodes the JSON answer into a T,
+// a 204 or empty body gives the zero T
+func DoJSON[T any](c *Client, req *http.Request, opts ...JSONOption) (T, error) {
+	var zero T
+	o := jsonOptions{maxBytes: DefaultMaxJSONBytes}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return zero, err
+	}
+	defer res.Body.Close()
+
+	decodeErr := func(err error) error {
+		return &DecodeError{
+			Method:      req.Method,
+			URL:         req.URL.Redacted(),
+			StatusCode:  res.StatusCode,
+			ContentType: res.Header.Get("Content-Type"),
+			Err:         err,
+		}
+	}
+	if res.StatusCode == http.StatusNoContent {
+		return zero, nil
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "" && !isJSON(ct) {
+		return zero, decodeErr(fmt.Errorf("want a JSON content type"))
+	}
+
+	// read one byte more than allowed to tell "exactly max" from "too much"
+	body, err := io.ReadAll(io.LimitReader(res.Body, o.maxBytes+1))
+	if err != nil {
+		return zero, decodeErr(err)
+	}
+	if int64(len(body)) > o.maxBytes {
+		return zero, decodeErr(fmt.Errorf("%w, limit is %d bytes", ErrResponseTooLarge, o.maxBytes))
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return zero, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if o.strict {
+		dec.DisallowUnknownFields()
+	}
+	var v T
+	if err := dec.Decode(&v); err != nil {
+		return zero, decodeErr(err)
+	}
+	if dec.More() {
+		return zero, decodeErr(errors.New("more than one JSON value in the body"))
+	}
+	return v, nil
+}
+
+// isJSON accepts application/json and the +json types like application/problem+json
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type course struct {
+	Name  string `json:"name"`
+	Price int    `json:"price"`
+}
+
+func jsonServer(t *testing.T) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Accept") != "application/json" {
+				http.Error(w, "want JSON headers", http.StatusUnsupportedMediaType)
+				return
+			}
+			var c course
+			json.NewDecoder(r.Body).Decode(&c)
+			c.Price *= 2
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			json.NewEncoder(w).Encode(c)
+		case "/extra":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"go","price":1,"level":"easy"}`))
+		case "/big":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"` + strings.Repeat("a", 100) + `"}`))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<h1>hi</h1>`))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c, err := New(Config{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPostJSON(t *testing.T) {
+	c := jsonServer(t)
+	got, err := PostJSON[course, course](context.Background(), c, "/echo", course{Name: "go", Price: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (course{Name: "go", Price: 10}) {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestGetJSONOptions(t *testing.T) {
+	c := jsonServer(t)
+	ctx := context.Background()
+
+	if got, err := GetJSON[course](ctx, c, "/extra"); err != nil || got.Name != "go" {
+		t.Errorf("lenient decode: %+v, %v", got, err)
+	}
+
+	var decodeErr *DecodeError
+	if _, err := GetJSON[course](ctx, c, "/extra", Strict()); !errors.As(err, &decodeErr) || decodeErr.StatusCode != 200 {
+		t.Errorf("strict decode: err = %v, want a DecodeError", err)
+	}
+	if _, err := GetJSON[course](ctx, c, "/big", MaxBytes(50)); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("err = %v, want ErrResponseTooLarge", err)
+	}
+	if _, err := GetJSON[course](ctx, c, "/html"); !errors.As(err, &decodeErr) || decodeErr.ContentType != "text/html" {
+		t.Errorf("err = %v, want a DecodeError naming text/html", err)
+	}
+	if got, err := GetJSON[*course](ctx, c, "/empty"); err != nil || got != nil {
+		t.Errorf("204: %v, %v, want nil, nil", got, err)
+	}
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy says when a failed request is sent again
+// the zero value never retries
+type RetryPolicy struct {
+	// MaxAttempts counts the first try too, 0 and 1 mean no retries
+	MaxAttempts int
+	// the wait before attempt n is a random duration up to BaseDelay*2^(n-2), capped at MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// RetryStatus lists the statuses worth another try
+	RetryStatus []int
+	// RetryNonIdempotent also retries POST and PATCH, which may then run twice
+	// a request with an Idempotency-Key header is retried either way
+	RetryNonIdempotent bool
+	// OnAttempt is called after every attempt that failed, for logging
+	OnAttempt func(Attempt)
+}
+
+// DefaultRetryPolicy tries three times on connection errors and timeouts, 429 and 502 to 504
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		RetryStatus: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// Attempt describes one failed try, Retry and Delay tell what happens next
+type Attempt struct {
+	Request *http.Request
+	// Number starts at 1
+	Number int
+	// Err is the transport error or a *StatusError
+	Err   error
+	Retry bool
+	Delay time.Duration
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// decide says whether attempt n of req, which failed with err, is tried again and after how long
+func (p RetryPolicy) decide(req *http.Request, n int, err error) (time.Duration, bool) {
+	if n >= p.MaxAttempts {
+		return 0, false
+	}
+	// an open breaker is meant to fail fast, waiting here would defeat it
+	if errors.Is(err, ErrCircuitOpen) {
+		return 0, false
+	}
+	if !p.RetryNonIdempotent && !idempotent(req) {
+		return 0, false
+	}
+	// a body that cannot be read again cannot be sent again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return 0, false
+	}
+	// the caller gave up, trying again would fail the same way
+	if req.Context().Err() != nil {
+		return 0, false
+	}
+
+	delay := p.backoff(n)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		if !transient(err) {
+			return 0, false
+		}
+		return delay, true
+	}
+	if !slices.Contains(p.RetryStatus, statusErr.StatusCode) {
+		return 0, false
+	}
+	if wait, ok := retryAfter(statusErr.Header); ok {
+		// the server asks for longer than we are willing to wait
+		if p.MaxDelay > 0 && wait > p.MaxDelay {
+			return 0, false
+		}
+		delay = max(delay, wait)
+	}
+	return delay, true
+}
+
+// transient reports whether a transport error may go away on its own, a refused
+// or dropped connection or a timeout, a bad certificate or URL fails the same way every time
+func transient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		// the server closed a kept alive connection as the request went out
+		errors.Is(err, io.EOF)
+}
+
+// backoff is "full jitter", a random wait up to the exponential step,
+// so clients that failed together do not come back together
+func (p RetryPolicy) backoff(n int) time.Duration {
+	step := p.MaxDelay
+	// past 2^30 the shift overflows, MaxDelay is reached long before
+	if n <= 30 {
+		if s := p.BaseDelay << (n - 1); s > 0 && (p.MaxDelay <= 0 || s < p.MaxDelay) {
+			step = s
+		}
+	}
+	if step <= 0 {
+		return 0
+	}
+	return rand.N(step)
+}
+
+// retryAfter reads Retry-After as seconds or as an HTTP date
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// flaky answers the given statuses in turn, then 200 with the body it received
+func flaky(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			if statuses[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			http.Error(w, "try again", statuses[n-1])
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestClient(t *testing.T, base string, policy RetryPolicy) *Client {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.BaseURL = base
+	cfg.Retry = policy
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func fastPolicy() RetryPolicy {
+	p := DefaultRetryPolicy()
+	p.BaseDelay = time.Millisecond
+	return p
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	srv, calls := flaky(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	var attempts []Attempt
+	policy := fastPolicy()
+	policy.OnAttempt = func(a Attempt) { attempts = append(attempts, a) }
+
+	res, err := newTestClient(t, srv.URL, policy).Get(context.Background(), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server saw %d calls, want 3", got)
+	}
+	if len(attempts) != 2 || attempts[0].Number != 1 || !attempts[1].Retry {
+		t.Errorf("OnAttempt got %+v, want two retried attempts", attempts)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	srv, calls := flaky(t, 503, 503, 503, 503)
+
+	_, err := newTestClient(t, srv.URL, fastPolicy()).Get(context.Background(), "/")
+	if StatusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want a 503 StatusError", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server saw %d calls, want MaxAttempts 3", got)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	srv, calls := flaky(t, http.StatusNotFound)
+
+	_, err := newTestClient(t, srv.URL, fastPolicy()).Get(context.Background(), "/")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Body != "try again" {
+		t.Fatalf("err = %v, want a StatusError with the body", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server saw %d calls, 404 must not be retried", got)
+	}
+}
+
+func TestPostIsRetriedOnlyWhenAllowed(t *testing.T) {
+	srv, calls := flaky(t, 503)
+	_, err := newTestClient(t, srv.URL, fastPolicy()).Post(context.Background(), "/", "text/plain", strings.NewReader("hi"))
+	if StatusCode(err) != 503 || calls.Load() != 1 {
+		t.Fatalf("POST: err = %v after %d calls, want one 503", err, calls.Load())
+	}
+
+	srv, calls = flaky(t, 503)
+	policy := fastPolicy()
+	policy.RetryNonIdempotent = true
+	res, err := newTestClient(t, srv.URL, policy).Post(context.Background(), "/", "text/plain", strings.NewReader("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	// the body has to be sent again in full on the retry
+	if body, _ := io.ReadAll(res.Body); string(body) != "hi" || calls.Load() != 2 {
+		t.Errorf("retried POST echoed %q after %d calls, want \"hi\" after 2", body, calls.Load())
+	}
+}
+
+func TestRetryAfterIsHonored(t *testing.T) {
+	srv, _ := flaky(t, http.StatusTooManyRequests)
+
+	start := time.Now()
+	res, err := newTestClient(t, srv.URL, fastPolicy()).Get(context.Background(), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %v, Retry-After asked for 1s", waited)
+	}
+
+	// a Retry-After beyond MaxDelay is not waited for
+	srv, calls := flaky(t, http.StatusTooManyRequests)
+	policy := fastPolicy()
+	policy.MaxDelay = 100 * time.Millisecond
+	_, err = newTestClient(t, srv.URL, policy).Get(context.Background(), "/")
+	if StatusCode(err) != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Errorf("err = %v after %d calls, want the 429 right away", err, calls.Load())
+	}
+}
+
+func TestRetryOnConnectionError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	base := srv.URL
+	srv.Close()
+
+	var attempts int
+	policy := fastPolicy()
+	policy.OnAttempt = func(Attempt) { attempts++ }
+	if _, err := newTestClient(t, base, policy).Get(context.Background(), "/"); err == nil {
+		t.Fatal("want a connection error")
+	}
+	if attempts != 3 {
+		t.Errorf("%d attempts, want 3", attempts)
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	srv, _ := flaky(t, 503, 503, 503)
+	policy := fastPolicy()
+	policy.BaseDelay = time.Hour
+	policy.MaxDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := newTestClient(t, srv.URL, policy).Get(ctx, "/")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context deadline", err)
+	}
+}
+
+func TestNoRetryOnPermanentErrors(t *testing.T) {
+	// the client does not trust the test certificate, so every try fails the same way
+	tlsSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsSrv.Close()
+
+	for name, target := range map[string]string{
+		"bad certificate":    tlsSrv.URL,
+		"unsupported scheme": "ftp://example.com/file",
+	} {
+		var attempts int
+		policy := fastPolicy()
+		policy.OnAttempt = func(Attempt) { attempts++ }
+		if _, err := newTestClient(t, "", policy).Get(context.Background(), target); err == nil {
+			t.Fatalf("%s: want an error", name)
+		}
+		if attempts != 1 {
+			t.Errorf("%s: %d attempts, want 1", name, attempts)
+		}
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&url.Error{Op: "Get", URL: "http://x", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, true},
+		{&url.Error{Op: "Get", URL: "http://x", Err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}, true},
+		{&url.Error{Op: "Get", URL: "http://x", Err: io.ErrUnexpectedEOF}, true},
+		{&url.Error{Op: "Get", URL: "http://x", Err: context.DeadlineExceeded}, true},
+		{&url.Error{Op: "Get", URL: "http://x", Err: &net.DNSError{Err: "no such host", Name: "x", IsNotFound: true}}, false},
+		{&url.Error{Op: "Get", URL: "http://x", Err: errors.New("tls: failed to verify certificate")}, false},
+		{errors.New("unsupported protocol scheme"), false},
+	}
+	for _, tt := range tests {
+		if got := transient(tt.err); got != tt.want {
+			t.Errorf("transient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
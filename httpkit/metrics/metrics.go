@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SangamSilwal/httpkit/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Unmatched is the route label of requests no route matched,
+// so probing random paths cannot blow up the number of series
+const Unmatched = "unmatched"
+
+// Other is the method label of requests with a non-standard method,
+// the method is chosen by the client so it must not become a label as is
+const Other = "OTHER"
+
+var methods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true,
+	http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true,
+	http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// Metrics holds the HTTP metrics of one service in its own registry,
+// both APIs use the same names so dashboards work for either
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+// New registers the HTTP metrics plus the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time spent serving HTTP requests, by method, route template and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests currently being served.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.duration, m.inFlight,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Start counts a request as in flight, call the returned func with
+// the route and status once it is answered
+func (m *Metrics) Start(method string) func(route string, status int) {
+	if !methods[method] {
+		method = Other
+	}
+	start := time.Now()
+	m.inFlight.Inc()
+	return func(route string, status int) {
+		m.inFlight.Dec()
+		if route == "" {
+			route = Unmatched
+		}
+		code := strconv.Itoa(status)
+		m.requests.WithLabelValues(method, route, code).Inc()
+		m.duration.WithLabelValues(method, route, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Middleware measures every request going through it, route gives the template label
+func (m *Metrics) Middleware(route middleware.RouteFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := m.Start(r.Method)
+			rec := middleware.NewResponseRecorder(w)
+			next.ServeHTTP(rec, r)
+			done(route(r), rec.Status)
+		})
+	}
+}
+
+// Handler serves the registry in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestUnknownMethodsShareOneLabel(t *testing.T) {
+	m := New()
+	h := m.Middleware(func(*http.Request) string { return "/courses" })(http.NotFoundHandler())
+	for _, method := range []string{"M0", "M1", "M2", http.MethodGet} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/courses", nil))
+	}
+
+	body := scrape(t, m)
+	if strings.Contains(body, `method="M0"`) || strings.Contains(body, `method="M1"`) {
+		t.Error("a made up method became a label")
+	}
+	for _, want := range []string{
+		`http_requests_total{method="OTHER",route="/courses",status="404"} 3`,
+		`http_requests_total{method="GET",route="/courses",status="404"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+func TestEmptyRouteIsUnmatched(t *testing.T) {
+	m := New()
+	m.Start(http.MethodGet)("", http.StatusNotFound)
+	if want := `http_requests_total{method="GET",route="unmatched",status="404"} 1`; !strings.Contains(scrape(t, m), want) {
+		t.Errorf("metrics lack %s", want)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/SangamSilwal/httpkit/problem"
+)
+
+// DefaultMaxBodyBytes is plenty for the JSON bodies of the APIs
+const DefaultMaxBodyBytes = 1 << 20
+
+// LimitBody caps r.Body at n bytes, reading past it fails with *http.MaxBytesError
+// when Content-Length already says too much it answers 413 and returns false
+func LimitBody(w http.ResponseWriter, r *http.Request, n int64) bool {
+	if r.ContentLength > n {
+		WriteTooLarge(w, r, n)
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, n)
+	return true
+}
+
+// BodyLimit applies LimitBody to every request
+func BodyLimit(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if LimitBody(w, r, n) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// TooLarge reports whether err came from reading past a LimitBody cap,
+// it returns the limit so the handler can answer with WriteTooLarge
+func TooLarge(err error) (int64, bool) {
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		return tooBig.Limit, true
+	}
+	return 0, false
+}
+
+// WriteTooLarge answers 413
+func WriteTooLarge(w http.ResponseWriter, r *http.Request, n int64) {
+	problem.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body must be at most %d bytes", n))
+}
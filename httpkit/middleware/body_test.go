@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	read := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			if n, ok := TooLarge(err); ok {
+				WriteTooLarge(w, r, n)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	h := BodyLimit(8)(read)
+
+	tests := []struct {
+		name string
+		body string
+		// chunked hides the length, so the limit hits while reading
+		chunked bool
+		want    int
+	}{
+		{"small", "12345678", false, http.StatusOK},
+		{"content length too big", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"chunked too big", "123456789", true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if tt.want == http.StatusRequestEntityTooLarge && rec.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: Content-Type = %q", tt.name, rec.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SangamSilwal/httpkit/problem"
+)
+
+// CORSConfig says which browser origins may call the API
+type CORSConfig struct {
+	// AllowedOrigins are full origins like https://app.example.com, "*" allows any
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are response headers scripts may read
+	ExposedHeaders []string
+	// AllowCredentials lets the browser send cookies and Authorization from
+	// the listed origins, origins only matched by "*" never get credentials
+	AllowCredentials bool
+	// MaxAge is how long a browser may cache a preflight answer
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig allows no origin until some are configured
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders: []string{"Accept", "Accept-Language", "Authorization", "Content-Type", RequestIDHeader, "If-None-Match"},
+		ExposedHeaders: []string{"Location", "ETag", RequestIDHeader, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// RegisterFlags adds -cors-origins, -cors-credentials and -cors-max-age to fs,
+// CORS_ORIGINS in the environment sets the default origins
+func (c *CORSConfig) RegisterFlags(fs *flag.FlagSet) {
+	if v, ok := os.LookupEnv("CORS_ORIGINS"); ok {
+		c.AllowedOrigins = splitList(v)
+	}
+	fs.Func("cors-origins", "comma separated origins allowed to call the API, * for any (env CORS_ORIGINS)", func(s string) error {
+		c.AllowedOrigins = splitList(s)
+		return nil
+	})
+	fs.BoolVar(&c.AllowCredentials, "cors-credentials", c.AllowCredentials, "let browsers send credentials from the listed origins, never for *")
+	fs.DurationVar(&c.MaxAge, "cors-max-age", c.MaxAge, "how long browsers may cache a preflight answer")
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func (c CORSConfig) allowOrigin(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") || slices.Contains(c.AllowedOrigins, origin)
+}
+
+// CORS answers preflight requests itself and adds the Access-Control headers
+// to the rest, requests without an Origin header pass untouched
+func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			// the answer depends on Origin, caches must not share it between origins
+			w.Header().Add("Vary", "Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !cfg.allowOrigin(origin) {
+				if preflight {
+					problem.Error(w, r, http.StatusForbidden, "origin "+origin+" may not call this API")
+					return
+				}
+				// without CORS headers the browser hides the response from the script
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			if slices.Contains(cfg.AllowedOrigins, origin) {
+				h.Set("Access-Control-Allow-Origin", origin)
+				if cfg.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+			} else {
+				// echoing any origin with credentials would let every site act as the user
+				h.Set("Access-Control-Allow-Origin", "*")
+			}
+
+			if !preflight {
+				if exposed != "" {
+					h.Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
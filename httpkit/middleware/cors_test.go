@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+func corsRequest(h http.Handler, method, origin string, preflight bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/courses", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if preflight {
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORS(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://app.example"}
+	cfg.AllowCredentials = true
+	h := CORS(cfg)(ok)
+
+	rec := corsRequest(h, http.MethodOptions, "https://app.example", true)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("preflight status = %d, want 204", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("listed origin did not get credentials")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(got, http.MethodPost) {
+		t.Errorf("Allow-Methods = %q", got)
+	}
+	if rec.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("Max-Age = %q, want 600", rec.Header().Get("Access-Control-Max-Age"))
+	}
+
+	rec = corsRequest(h, http.MethodGet, "https://app.example", false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Access-Control-Expose-Headers"), "Location") {
+		t.Errorf("simple request: %d, exposed %q", rec.Code, rec.Header().Get("Access-Control-Expose-Headers"))
+	}
+
+	rec = corsRequest(h, http.MethodOptions, "https://evil.example", true)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("preflight from another origin: %d, Allow-Origin %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+	rec = corsRequest(h, http.MethodGet, "https://evil.example", false)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("request from another origin: %d, Allow-Origin %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	rec = corsRequest(h, http.MethodGet, "", false)
+	if rec.Code != http.StatusOK || !slices.Contains(rec.Header().Values("Vary"), "Origin") {
+		t.Errorf("same origin request: %d, Vary %q", rec.Code, rec.Header().Values("Vary"))
+	}
+}
+
+func TestCORSWildcardNeverGrantsCredentials(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"*", "https://app.example"}
+	cfg.AllowCredentials = true
+	h := CORS(cfg)(ok)
+
+	for _, preflight := range []bool{true, false} {
+		rec := corsRequest(h, http.MethodOptions, "https://evil.example", preflight)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("Allow-Origin = %q, want * and not the echoed origin", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("Allow-Credentials = %q for an origin only matched by *", got)
+		}
+	}
+
+	rec := corsRequest(h, http.MethodGet, "https://app.example", false)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example" || rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("the listed origin lost its credentials next to *")
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RouteFunc returns the route template that matched r, like /courses/{id}
+// logging the template instead of the path keeps ids out of the route label
+type RouteFunc func(r *http.Request) string
+
+// AccessLog writes one structured line per request with method, route,
+// status, latency and response size
+func AccessLog(logger *slog.Logger, route RouteFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := NewResponseRecorder(w)
+			next.ServeHTTP(rec, r)
+
+			level := slog.LevelInfo
+			if rec.Status >= 500 {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(r.Context(), level, "request",
+				slog.String("request_id", RequestIDFrom(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("route", route(r)),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.Status),
+				slog.Duration("latency", time.Since(start)),
+				slog.Int("bytes", rec.Bytes),
+			)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// ResponseRecorder wraps a ResponseWriter and remembers the status and body size
+type ResponseRecorder struct {
+	http.ResponseWriter
+	Status      int
+	Bytes       int
+	WroteHeader bool
+}
+
+// NewResponseRecorder reuses w when it already is a recorder,
+// so stacked middleware share a single one
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	if rec, ok := w.(*ResponseRecorder); ok {
+		return rec
+	}
+	return &ResponseRecorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (r *ResponseRecorder) WriteHeader(status int) {
+	if r.WroteHeader {
+		return
+	}
+	r.Status = status
+	r.WroteHeader = true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *ResponseRecorder) Write(b []byte) (int, error) {
+	if !r.WroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.Bytes += n
+	return n, err
+}
+
+func (r *ResponseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the real writer
+func (r *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/SangamSilwal/httpkit/problem"
+)
+
+// Recover turns a panic in a handler into a 500 problem response and logs the stack,
+// instead of dropping the connection without an answer
+func Recover(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := NewResponseRecorder(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					// the handler asked to abort the response, let net/http do it
+					panic(v)
+				}
+
+				logger.ErrorContext(r.Context(), "panic in handler",
+					slog.String("request_id", RequestIDFrom(r.Context())),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("panic", fmt.Sprint(v)),
+					slog.String("stack", string(debug.Stack())),
+				)
+				if rec.WroteHeader {
+					// part of the response is already out, nothing sensible to add
+					return
+				}
+				problem.Error(rec, r, http.StatusInternalServerError, "the server hit an unexpected error")
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r.Context())
+	}))
+
+	tests := []struct {
+		name, incoming string
+		kept           bool
+	}{
+		{"valid", "abc-123_DEF.456", true},
+		{"missing", "", false},
+		{"space", "abc 123", false},
+		{"newline", "abc\n123", false},
+		{"non ASCII", "ähm", false},
+		{"too long", strings.Repeat("a", 129), false},
+		{"longest allowed", strings.Repeat("a", 128), true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.incoming != "" {
+			req.Header.Set(RequestIDHeader, tt.incoming)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(RequestIDHeader)
+		if got != seen {
+			t.Errorf("%s: response has %q, context has %q", tt.name, got, seen)
+		}
+		if tt.kept && got != tt.incoming {
+			t.Errorf("%s: %q was replaced by %q", tt.name, tt.incoming, got)
+		}
+		if !tt.kept && (got == tt.incoming || len(got) != 32) {
+			t.Errorf("%s: got %q, want a fresh 32 character id", tt.name, got)
+		}
+	}
+}
+
+func TestRequestIDFromOutsideARequest(t *testing.T) {
+	if id := RequestIDFrom(httptest.NewRequest(http.MethodGet, "/", nil).Context()); id != "" {
+		t.Errorf("RequestIDFrom = %q, want empty", id)
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := DefaultSecurityConfig()
+	rec := httptest.NewRecorder()
+	SecurityHeaders(cfg)(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	for header, want := range map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "no-referrer",
+		"Content-Security-Policy":   cfg.ContentSecurityPolicy,
+		"Strict-Transport-Security": "",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	cfg.HSTS = true
+	cfg.ContentSecurityPolicy = ""
+	rec = httptest.NewRecorder()
+	SecurityHeaders(cfg)(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=31536000; includeSubDomains" {
+		t.Errorf("Strict-Transport-Security = %q", got)
+	}
+	if _, set := rec.Header()["Content-Security-Policy"]; set {
+		t.Error("an empty policy was still sent")
+	}
+}
@@ -0,0 +1,174 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SangamSilwal/httpkit/problem"
+)
+
+// Config is one limit, Requests per Per with bursts of up to Burst requests
+// Burst 0 means Requests
+type Config struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// Limiter keeps one token bucket per key, a key is a client IP or a user id
+// buckets live in memory and idle ones are dropped, so a restart forgets everything
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	rate    float64 // tokens per second
+	burst   float64
+	policy  string
+	// a bucket idle this long is full again, so it can be dropped
+	idle      time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns a Limiter for cfg, or nil when cfg.Requests is 0,
+// a nil Limiter lets every request through
+func New(cfg Config) *Limiter {
+	if cfg.Requests <= 0 {
+		return nil
+	}
+	if cfg.Per <= 0 {
+		cfg.Per = time.Minute
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.Requests
+	}
+	rate := float64(cfg.Requests) / cfg.Per.Seconds()
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		rate:    rate,
+		burst:   float64(cfg.Burst),
+		policy:  fmt.Sprintf("%d;w=%d;burst=%d", cfg.Requests, int(math.Ceil(cfg.Per.Seconds())), cfg.Burst),
+		idle:    time.Duration(float64(cfg.Burst) / rate * float64(time.Second)),
+		now:     time.Now,
+	}
+}
+
+// Decision is the answer for one request
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed, 0 when Allowed
+	RetryAfter time.Duration
+	Policy     string
+}
+
+// Allow takes one token from the bucket of key
+func (l *Limiter) Allow(key string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	d := Decision{Limit: int(l.burst), Policy: l.policy}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = l.wait(1 - b.tokens)
+	}
+	d.Remaining = int(b.tokens)
+	d.Reset = l.wait(l.burst - b.tokens)
+	return d
+}
+
+func (l *Limiter) wait(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep drops buckets that refilled completely, at most once per idle period,
+// callers must hold the lock
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// seconds rounds up, a client told 0 would retry straight away
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// WriteHeaders sets the RateLimit-* headers, and Retry-After when the request was denied
+func (d Decision) WriteHeaders(h http.Header) {
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", seconds(d.Reset))
+	h.Set("RateLimit-Policy", d.Policy)
+	if !d.Allowed {
+		h.Set("Retry-After", seconds(d.RetryAfter))
+	}
+}
+
+// Deny answers a denied request with a 429 problem
+func Deny(w http.ResponseWriter, r *http.Request, d Decision) {
+	problem.Error(w, r, http.StatusTooManyRequests,
+		"rate limit exceeded, retry in "+seconds(d.RetryAfter)+" seconds")
+}
+
+// KeyFunc picks the bucket for a request
+type KeyFunc func(r *http.Request) string
+
+// ByIP keys on the remote address, X-Forwarded-For is ignored because
+// any client can set it, put a proxy-aware KeyFunc in front when needed
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// Middleware limits every request going through it, a nil Limiter does nothing
+func Middleware(l *Limiter, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := l.Allow(key(r))
+			d.WriteHeaders(w.Header())
+			if !d.Allowed {
+				Deny(w, r, d)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source the tests move by hand
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func testLimiter(cfg Config) (*Limiter, *clock) {
+	c := &clock{t: time.Unix(1_700_000_000, 0)}
+	l := New(cfg)
+	l.now = c.now
+	return l, c
+}
+
+func TestNewWithoutRequestsIsNil(t *testing.T) {
+	if l := New(Config{}); l != nil {
+		t.Fatal("New with 0 requests is not nil")
+	}
+	rec := httptest.NewRecorder()
+	Middleware(nil, ByIP)(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("a nil Limiter got in the way: %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestBurstThenRefill(t *testing.T) {
+	l, c := testLimiter(Config{Requests: 2, Per: time.Second})
+
+	for i := range 2 {
+		if d := l.Allow("a"); !d.Allowed || d.Remaining != 1-i {
+			t.Fatalf("request %d: %+v", i+1, d)
+		}
+	}
+	d := l.Allow("a")
+	if d.Allowed {
+		t.Fatal("third request within the burst was allowed")
+	}
+	if d.RetryAfter != 500*time.Millisecond || d.Reset != time.Second {
+		t.Errorf("RetryAfter = %v, Reset = %v, want 500ms and 1s", d.RetryAfter, d.Reset)
+	}
+	if other := l.Allow("b"); !other.Allowed {
+		t.Error("another key shares the bucket")
+	}
+
+	c.advance(500 * time.Millisecond)
+	if d := l.Allow("a"); !d.Allowed || d.Remaining != 0 {
+		t.Errorf("after refilling one token: %+v", d)
+	}
+}
+
+func TestIdleBucketsAreDropped(t *testing.T) {
+	l, c := testLimiter(Config{Requests: 10, Per: time.Minute})
+	l.Allow("a")
+	l.Allow("b")
+
+	c.advance(l.idle)
+	l.Allow("c")
+	if len(l.buckets) != 1 {
+		t.Errorf("%d buckets after the idle period, want only c", len(l.buckets))
+	}
+}
+
+func TestWriteHeaders(t *testing.T) {
+	d := Decision{Limit: 10, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 100 * time.Millisecond, Policy: "10;w=60;burst=10"}
+	h := http.Header{}
+	d.WriteHeaders(h)
+	for name, want := range map[string]string{
+		"RateLimit-Limit":     "10",
+		"RateLimit-Remaining": "0",
+		// seconds round up, 0 would invite an immediate retry
+		"RateLimit-Reset":  "2",
+		"Retry-After":      "1",
+		"RateLimit-Policy": "10;w=60;burst=10",
+	} {
+		if got := h.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	d.Allowed = true
+	h = http.Header{}
+	d.WriteHeaders(h)
+	if h.Get("Retry-After") != "" {
+		t.Error("Retry-After on an allowed request")
+	}
+}
+
+func TestMiddlewareDenies(t *testing.T) {
+	l, _ := testLimiter(Config{Requests: 1, Per: time.Minute})
+	h := Middleware(l, ByIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/courses", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := serve("10.0.0.1:1000"); rec.Code != http.StatusOK {
+		t.Fatalf("first request = %d", rec.Code)
+	}
+	// another port of the same host shares the bucket
+	rec := serve("10.0.0.1:2000")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Content-Type") != "application/problem+json" || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("headers = %v", rec.Header())
+	}
+	if !strings.Contains(rec.Body.String(), "retry in 60 seconds") {
+		t.Errorf("body = %s", rec.Body)
+	}
+	if rec := serve("10.0.0.2:1000"); rec.Code != http.StatusOK {
+		t.Errorf("another client = %d, want 200", rec.Code)
+	}
+}
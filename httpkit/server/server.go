@@ -0,0 +1,148 @@
+// Package server runs an http.Handler with sane timeouts and a graceful shutdown
+// on SIGINT/SIGTERM, so in-flight requests finish before the process exits
+package server
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Config holds everything needed to build the http.Server
+type Config struct {
+	Host              string
+	Port              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout is how long in-flight requests get to finish after a signal
+	ShutdownTimeout time.Duration
+	MaxHeaderBytes  int
+}
+
+// DefaultConfig listens on every interface at port
+func DefaultConfig(port string) Config {
+	return Config{
+		Port:              port,
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		ShutdownTimeout:   20 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+	}
+}
+
+// Addr is the host:port the server listens on
+func (c Config) Addr() string {
+	return net.JoinHostPort(c.Host, c.Port)
+}
+
+// RegisterFlags adds -host, -port, -read-timeout ... to fs
+// the environment (HOST, PORT, READ_TIMEOUT ...) overrides the defaults
+// and a flag given on the command line overrides the environment
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Host, "host", envString("HOST", c.Host), "interface to listen on, empty means all (env HOST)")
+	fs.StringVar(&c.Port, "port", envString("PORT", c.Port), "port to listen on (env PORT)")
+	fs.DurationVar(&c.ReadTimeout, "read-timeout", envDuration("READ_TIMEOUT", c.ReadTimeout), "max time to read a request (env READ_TIMEOUT)")
+	fs.DurationVar(&c.ReadHeaderTimeout, "read-header-timeout", envDuration("READ_HEADER_TIMEOUT", c.ReadHeaderTimeout), "max time to read request headers (env READ_HEADER_TIMEOUT)")
+	fs.DurationVar(&c.WriteTimeout, "write-timeout", envDuration("WRITE_TIMEOUT", c.WriteTimeout), "max time to write a response (env WRITE_TIMEOUT)")
+	fs.DurationVar(&c.IdleTimeout, "idle-timeout", envDuration("IDLE_TIMEOUT", c.IdleTimeout), "max time a keep-alive connection stays idle (env IDLE_TIMEOUT)")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", envDuration("SHUTDOWN_TIMEOUT", c.ShutdownTimeout), "time in-flight requests get to finish on shutdown (env SHUTDOWN_TIMEOUT)")
+	fs.IntVar(&c.MaxHeaderBytes, "max-header-bytes", envInt("MAX_HEADER_BYTES", c.MaxHeaderBytes), "max size of request headers (env MAX_HEADER_BYTES)")
+}
+
+func envString(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("ignoring %s=%q: %v", key, v, err)
+		return fallback
+	}
+	return d
+}
+
+func envInt(key string, fallback int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("ignoring %s=%q: %v", key, v, err)
+		return fallback
+	}
+	return n
+}
+
+// New builds the http.Server described by cfg
+func New(cfg Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// Run serves handler until SIGINT or SIGTERM arrives, then stops accepting
+// connections and waits up to cfg.ShutdownTimeout for in-flight requests
+func Run(cfg Config, handler http.Handler) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return RunContext(ctx, cfg, handler)
+}
+
+// RunContext is Run with the shutdown triggered by ctx instead of a signal
+func RunContext(ctx context.Context, cfg Config, handler http.Handler) error {
+	srv := New(cfg, handler)
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", srv.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		// the server never started, e.g. the port is taken
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("shutting down, waiting up to %s for in-flight requests", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// deadline passed, cut the remaining connections
+		srv.Close()
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}